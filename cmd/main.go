@@ -1,33 +1,155 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
-	"os/signal"
-	"syscall"
+	"strings"
 
 	"openledger/internal/bot"
+	"openledger/pkg/openledger"
 )
 
+// 命令行参数,显式指定时优先于配置文件
+var (
+	configPath   = flag.String("config", "config.json", "path to the optional JSON config file")
+	profile      = flag.String("profile", "", fmt.Sprintf("endpoint profile: %s or a profile from the config file (default %s)", strings.Join(openledger.ProfileNames(), ", "), openledger.DefaultProfile))
+	accountsFile = flag.String("accounts", "", "path to the accounts file, plain addresses or CSV with a header (default accounts.txt)")
+	watch        = flag.Bool("watch", false, "reload automatically when the accounts file or the config file changes")
+	tui          = flag.Bool("tui", false, "show an interactive dashboard instead of scrolling logs")
+	controlAddr  = flag.String("control-addr", "", "loopback address for the local status and control API, e.g. 127.0.0.1:8788")
+	controlToken = flag.String("control-token", "", "bearer token for the control API (defaults to $OPENLEDGER_CONTROL_TOKEN, generated when empty)")
+	dryRun       = flag.Bool("dry-run", false, "only run read-only requests, log claims and WebSocket messages instead of sending them")
+	record       = flag.String("record", "", "directory to record redacted HTTP and WebSocket traffic into, one cassette file per account")
+	strictDecode = flag.Bool("strict-decode", false, "also report API response fields that the bot does not know about, once per endpoint")
+	proxy        = flag.String("proxy", "", "proxy source: auto, manual or none (run asks when empty, other commands use none)")
+	accountMask  = flag.String("account-mask", "", "how accounts are shown in logs, metrics and APIs: partial, alias or pseudonym (default partial)")
+)
+
+// 子命令说明
+var commands = []struct {
+	name        string
+	description string
+}{
+	{bot.CommandRun, "run all tasks for every enabled account until interrupted (default)"},
+	{bot.CommandStatus, "print total and today's points"},
+	{bot.CommandCheckin, "claim the daily check-in if it is still available"},
+	{bot.CommandTiers, "list tier rewards, claim them with --claim"},
+	{bot.CommandToken, "print when a freshly generated access token expires"},
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [flags] [command] [command flags]\n\nCommands:\n", os.Args[0])
+	for _, c := range commands {
+		fmt.Fprintf(out, "  %-8s %s\n", c.name, c.description)
+	}
+	fmt.Fprintf(out, "\nFlags:\n")
+	flag.PrintDefaults()
+}
+
 func main() {
-	// 创建一个新的bot实例
-	b := bot.NewOpenLedger()
+	// 解析命令行参数
+	flag.Usage = usage
+	flag.Parse()
+
+	command := bot.CommandRun
+	args := flag.Args()
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+
+	known := false
+	for _, c := range commands {
+		known = known || c.name == command
+	}
+	if !known {
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", command)
+		usage()
+		os.Exit(2)
+	}
+
+	// 子命令参数
+	commandFlags := flag.NewFlagSet(command, flag.ExitOnError)
+	jsonOutput := commandFlags.Bool("json", false, "print machine-readable JSON to stdout")
+	var options bot.CommandOptions
+	if command != bot.CommandRun {
+		commandFlags.StringVar(&options.Account, "account", "", "only this account: index (from 1), address or alias")
+	}
+	if command == bot.CommandTiers {
+		commandFlags.BoolVar(&options.Claim, "claim", false, "claim every unclaimed tier reward")
+	}
+	commandFlags.Parse(args)
+	options.JSON = *jsonOutput
 
-	// 设置信号处理
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	config, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Config error: %v\n", err)
+		os.Exit(1)
+	}
 
-	// 启动bot
-	go func() {
-		if err := b.Start(); err != nil {
-			fmt.Printf("Bot error: %v\n", err)
+	if command != bot.CommandRun {
+		if err := bot.Execute(command, config, options); err != nil {
+			fmt.Fprintf(os.Stderr, "%s failed: %s\n", command, bot.Redact(err.Error()))
 			os.Exit(1)
 		}
-	}()
-
-	fmt.Println("Bot is running. Press Ctrl+C to exit...")
-	// 等待中断信号
-	<-sigChan
-	fmt.Println("\nShutting down...")
-	b.Stop()
-} 
\ No newline at end of file
+		return
+	}
+
+	err = bot.Run(config, bot.RunOptions{
+		JSON:       options.JSON,
+		Watch:      *watch,
+		ConfigPath: *configPath,
+		LoadConfig: loadConfig,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Bot error: %s\n", bot.Redact(err.Error()))
+		os.Exit(1)
+	}
+}
+
+// loadConfig 读取配置文件并应用命令行参数
+func loadConfig() (bot.Config, error) {
+	config, err := bot.LoadConfig(*configPath)
+	if err != nil {
+		return config, err
+	}
+
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "profile":
+			config.Profile = *profile
+		case "accounts":
+			config.AccountsFile = *accountsFile
+		case "tui":
+			config.TUI = *tui
+		case "control-addr":
+			config.ControlAddr = *controlAddr
+		case "control-token":
+			config.ControlToken = *controlToken
+		case "dry-run":
+			config.DryRun = *dryRun
+		case "record":
+			config.Record = *record
+		case "strict-decode":
+			config.StrictDecode = *strictDecode
+		case "proxy":
+			config.Proxy = *proxy
+		case "account-mask":
+			config.AccountMask = *accountMask
+		}
+	})
+
+	if config.ControlToken == "" {
+		config.ControlToken = os.Getenv("OPENLEDGER_CONTROL_TOKEN")
+	}
+	if config.PseudonymKey == "" {
+		config.PseudonymKey = os.Getenv("OPENLEDGER_PSEUDONYM_KEY")
+	}
+
+	if err := config.Validate(); err != nil {
+		return config, err
+	}
+
+	return config, nil
+}
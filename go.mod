@@ -6,11 +6,10 @@ require (
 	github.com/fatih/color v1.15.0
 	github.com/google/uuid v1.6.0
 	github.com/gorilla/websocket v1.5.0
+	github.com/mattn/go-isatty v0.0.17
+	golang.org/x/crypto v0.17.0
 	golang.org/x/net v0.19.0
+	golang.org/x/sys v0.15.0
 )
 
-require (
-	github.com/mattn/go-colorable v0.1.13 // indirect
-	github.com/mattn/go-isatty v0.0.17 // indirect
-	golang.org/x/sys v0.15.0 // indirect
-)
+require github.com/mattn/go-colorable v0.1.13 // indirect
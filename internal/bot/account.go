@@ -2,30 +2,357 @@ package bot
 
 import (
 	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"os"
+	"strconv"
 	"strings"
+
+	"github.com/fatih/color"
 )
 
-// loadAccounts 从文件加载账号列表
-func (o *OpenLedger) loadAccounts() ([]string, error) {
-	file, err := os.Open("accounts.txt")
+// Account 账号配置
+type Account struct {
+	Address string       `json:"-"`
+	Alias   string       `json:"alias,omitempty"`
+	Tags    []string     `json:"tags,omitempty"`
+	Enabled bool         `json:"enabled"`
+	Tasks   AccountTasks `json:"tasks"`
+
+	line int
+	// index 在账号文件中的序号,从1开始
+	index int
+}
+
+// AccountTasks 账号需要运行的任务
+type AccountTasks struct {
+	Earning   bool `json:"earning"`
+	Checkin   bool `json:"checkin"`
+	Tier      bool `json:"tier"`
+	WebSocket bool `json:"websocket"`
+}
+
+// count 返回启用的任务数量
+func (t AccountTasks) count() int {
+	n := 0
+	for _, enabled := range []bool{t.Earning, t.Checkin, t.Tier, t.WebSocket} {
+		if enabled {
+			n++
+		}
+	}
+	return n
+}
+
+// newAccount 创建启用全部任务的账号配置
+func newAccount(address string) Account {
+	return Account{
+		Address: address,
+		Enabled: true,
+		Tasks: AccountTasks{
+			Earning:   true,
+			Checkin:   true,
+			Tier:      true,
+			WebSocket: true,
+		},
+	}
+}
+
+// accountsFile 返回账号文件路径
+func (o *OpenLedger) accountsFile() string {
+	return o.settings().accountsFile()
+}
+
+// accountsFile 返回配置中的账号文件路径
+func (c Config) accountsFile() string {
+	if c.AccountsFile != "" {
+		return c.AccountsFile
+	}
+	return "accounts.txt"
+}
+
+// accountFileError 账号文件中的所有无效行
+type accountFileError struct {
+	path     string
+	problems []string
+}
+
+func (e *accountFileError) Error() string {
+	return fmt.Sprintf("%s has %d invalid line(s):\n  %s", e.path, len(e.problems), strings.Join(e.problems, "\n  "))
+}
+
+// loadAccounts 从文件加载并校验账号列表
+// 支持两种格式: 每行一个钱包地址, 或带表头的CSV(address,alias,tags,enabled,earning,checkin,tier,websocket)
+func (o *OpenLedger) loadAccounts() ([]Account, error) {
+	return o.readAccounts(o.accountsFile())
+}
+
+// readAccounts 从指定文件加载并校验账号列表
+func (o *OpenLedger) readAccounts(path string) ([]Account, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+
+	var accounts []Account
+	var problems []string
+	if isAccountsCSV(data) {
+		accounts, problems, err = parseAccountsCSV(bytes.NewReader(data))
+	} else {
+		accounts, err = parseAccountsText(bytes.NewReader(data))
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to open accounts.txt: %w", err)
+		return nil, fmt.Errorf("error reading %s: %w", path, err)
+	}
+
+	// 校验地址并去重
+	seen := make(map[string]int, len(accounts))
+	valid := make([]Account, 0, len(accounts))
+	for _, account := range accounts {
+		if err := validateAddress(account.Address); err != nil {
+			problems = append(problems, fmt.Sprintf("line %d: %s: %v", account.line, account.Address, err))
+			continue
+		}
+
+		key := strings.ToLower(account.Address)
+		if first, ok := seen[key]; ok {
+			o.log(color.YellowString("%s line %d: duplicate of line %d, skipped", path, account.line, first))
+			continue
+		}
+		seen[key] = account.line
+		valid = append(valid, account)
 	}
-	defer file.Close()
 
-	var accounts []string
-	scanner := bufio.NewScanner(file)
+	if len(problems) > 0 {
+		return nil, &accountFileError{path: path, problems: problems}
+	}
+
+	return valid, nil
+}
+
+// parseAccountsText 解析每行一个地址的账号文件, # 之后的内容为注释
+func parseAccountsText(r io.Reader) ([]Account, error) {
+	var accounts []Account
+	scanner := bufio.NewScanner(r)
+	line := 0
 	for scanner.Scan() {
-		if account := strings.TrimSpace(scanner.Text()); account != "" {
+		line++
+		text := scanner.Text()
+		if i := strings.Index(text, "#"); i >= 0 {
+			text = text[:i]
+		}
+		if address := strings.TrimSpace(text); address != "" {
+			account := newAccount(address)
+			account.line = line
 			accounts = append(accounts, account)
 		}
 	}
 
 	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading accounts.txt: %w", err)
+		return nil, err
 	}
 
 	return accounts, nil
-} 
\ No newline at end of file
+}
+
+// validateAddress 校验钱包地址: 0x开头的20字节十六进制,大小写混合时校验EIP-55校验和
+func validateAddress(address string) error {
+	if !strings.HasPrefix(address, "0x") && !strings.HasPrefix(address, "0X") {
+		return fmt.Errorf("missing 0x prefix")
+	}
+
+	digits := address[2:]
+	if len(digits) != 40 {
+		return fmt.Errorf("expected 40 hex characters, got %d", len(digits))
+	}
+	if _, err := hex.DecodeString(digits); err != nil {
+		return fmt.Errorf("invalid hex characters")
+	}
+
+	if digits == strings.ToLower(digits) || digits == strings.ToUpper(digits) {
+		return nil
+	}
+
+	if expected := checksumAddress(digits); expected[2:] != digits {
+		return fmt.Errorf("EIP-55 checksum mismatch, expected %s", expected)
+	}
+	return nil
+}
+
+// checksumAddress 按EIP-55生成带校验和的地址
+func checksumAddress(digits string) string {
+	lower := strings.ToLower(digits)
+	hash := keccak256([]byte(lower))
+
+	result := []byte(lower)
+	for i, c := range result {
+		if c < 'a' || c > 'f' {
+			continue
+		}
+		nibble := hash[i/2]
+		if i%2 == 0 {
+			nibble >>= 4
+		}
+		if nibble&0x0f >= 8 {
+			result[i] = c - 'a' + 'A'
+		}
+	}
+	return "0x" + string(result)
+}
+
+// isAccountsCSV 第一行有效内容包含address列时视为CSV格式
+func isAccountsCSV(data []byte) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		for _, field := range strings.Split(line, ",") {
+			if strings.EqualFold(strings.TrimSpace(field), "address") {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+// parseAccountsCSV 解析带表头的CSV账号文件,空白单元格使用默认值,返回所有无效行
+func parseAccountsCSV(r io.Reader) ([]Account, []string, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "address", "alias", "tags", "enabled", "earning", "checkin", "tier", "websocket":
+			columns[name] = i
+		default:
+			return nil, nil, fmt.Errorf("unknown column %q", name)
+		}
+	}
+	if _, ok := columns["address"]; !ok {
+		return nil, nil, fmt.Errorf("missing address column")
+	}
+
+	var accounts []Account
+	var problems []string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		cell := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		address := cell("address")
+		if address == "" {
+			continue
+		}
+
+		account := newAccount(address)
+		account.line = line
+		account.Alias = cell("alias")
+		for _, tag := range strings.FieldsFunc(cell("tags"), func(r rune) bool { return r == ';' || r == ' ' }) {
+			account.Tags = append(account.Tags, tag)
+		}
+
+		flags := []struct {
+			name  string
+			value *bool
+		}{
+			{"enabled", &account.Enabled},
+			{"earning", &account.Tasks.Earning},
+			{"checkin", &account.Tasks.Checkin},
+			{"tier", &account.Tasks.Tier},
+			{"websocket", &account.Tasks.WebSocket},
+		}
+		valid := true
+		for _, flag := range flags {
+			value := cell(flag.name)
+			if value == "" {
+				continue
+			}
+			parsed, err := parseBool(value)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("line %d: invalid %s value %q", line, flag.name, value))
+				valid = false
+				continue
+			}
+			*flag.value = parsed
+		}
+
+		if valid {
+			accounts = append(accounts, account)
+		}
+	}
+
+	return accounts, problems, nil
+}
+
+// parseBool 解析布尔值,额外支持yes/no和on/off
+func parseBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "yes", "y", "on":
+		return true, nil
+	case "no", "n", "off":
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
+
+// setAccounts 更新账号配置
+func (o *OpenLedger) setAccounts(accounts []Account) {
+	o.accountMutex.Lock()
+	defer o.accountMutex.Unlock()
+
+	o.accounts = make(map[string]Account, len(accounts))
+	for i, account := range accounts {
+		account.index = i + 1
+		o.accounts[accountKey(account.Address)] = account
+	}
+}
+
+// accountKey 账号配置和运行时状态的键,钱包地址不区分大小写
+func accountKey(address string) string {
+	return strings.ToLower(address)
+}
+
+// accountConfig 获取账号配置,未知账号返回启用全部任务的默认配置
+func (o *OpenLedger) accountConfig(address string) Account {
+	o.accountMutex.Lock()
+	defer o.accountMutex.Unlock()
+
+	if account, ok := o.accounts[accountKey(address)]; ok {
+		return account
+	}
+	return newAccount(address)
+}
+
+// displayName 日志、面板、通知、指标和接口中显示的账号名称,有别名时使用别名,否则按 accountMask 遮盖
+func (o *OpenLedger) displayName(address string) string {
+	if alias := o.accountConfig(address).Alias; alias != "" {
+		return alias
+	}
+	return o.maskAccount(address)
+}
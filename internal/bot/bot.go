@@ -1,60 +1,128 @@
 package bot
 
 import (
-	"encoding/base64"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
 	"sync"
+	"sync/atomic"
 
 	"github.com/fatih/color"
-	"github.com/google/uuid"
+
+	"openledger/internal/cassette"
+	"openledger/pkg/openledger"
 )
 
 type OpenLedger struct {
-	extensionID string
-	proxies     []string
-	proxyIndex  int
-	proxyMutex  sync.Mutex
-	running     bool
-	wg          sync.WaitGroup
-	logger      *Logger
+	extensionID  string
+	config       Config
+	proxies      []string
+	proxyIndex   int
+	proxyMutex   sync.Mutex
+	running      atomic.Bool
+	useProxy     bool
+	started      bool
+	wg           sync.WaitGroup
+	logger       *Logger
+	output       io.Writer
+	configMutex  sync.Mutex
+	reloadMutex  sync.Mutex
+	accounts     map[string]Account
+	accountMutex sync.Mutex
+	runtimes     map[string]*accountRuntime
+	runtimeOrder []string
+	runtimeMutex sync.Mutex
+	dashboard    *dashboard
+	control      *controlServer
+	transport    http.RoundTripper
+	recorder     *cassette.Recorder
+	schemas      *schemaTracker
+	notifier     *notifier
+	events       *eventBus
+
+	// 自动生成的假名密钥
+	pseudonymOnce sync.Once
+	generatedKey  []byte
+
+	// 录制时每个账号的脱敏函数
+	redactors     map[string]cassette.Redactor
+	redactorMutex sync.Mutex
 }
 
-func NewOpenLedger() *OpenLedger {
-	logger, err := NewLogger()
-	if err != nil {
-		fmt.Printf("Failed to create logger: %v\n", err)
-		os.Exit(1)
+// Option 创建OpenLedger时的选项
+type Option func(*OpenLedger)
+
+// WithLogger 使用指定的日志记录器,Stop 时会关闭它
+func WithLogger(logger *Logger) Option {
+	return func(o *OpenLedger) { o.logger = logger }
+}
+
+// WithOutput 交互式提示和面板输出到w,未指定日志记录器时日志也写入w,默认为标准输出
+func WithOutput(w io.Writer) Option {
+	return func(o *OpenLedger) { o.output = w }
+}
+
+// NewOpenLedger 创建bot实例,未设置的任务间隔使用默认值,配置无效时返回错误
+func NewOpenLedger(config Config, opts ...Option) (*OpenLedger, error) {
+	config = config.withDefaults()
+	if err := config.Validate(); err != nil {
+		return nil, err
 	}
 
-	return &OpenLedger{
-		extensionID: "chrome-extension://ekbbplmjjgoobhdlffmgeokalelnmjjc",
+	o := &OpenLedger{
+		extensionID: openledger.DefaultExtensionID,
+		config:      config,
 		proxies:     make([]string, 0),
 		proxyIndex:  0,
-		running:     false,
-		logger:      logger,
+		output:      os.Stdout,
+		runtimes:    make(map[string]*accountRuntime),
+		schemas:     newSchemaTracker(),
+		events:      newEventBus(),
+	}
+	for _, opt := range opts {
+		opt(o)
 	}
+	if o.logger == nil {
+		o.logger = NewConsoleLogger(o.output)
+	}
+
+	// 运行时状态最先更新,日志和其他订阅者随后处理
+	o.Subscribe(o.trackEvent)
+	o.Subscribe(o.logEvent)
+	o.Subscribe(o.trackState)
+	return o, nil
 }
 
 func (o *OpenLedger) Start() error {
-	o.running = true
-
-	// 清理终端
-	o.clearTerminal()
-
-	// 显示欢迎信息
-	o.welcome()
+	o.running.Store(true)
 
 	o.log(color.GreenString("Starting OpenLedger Bot..."))
 	o.printDivider()
 
+	// 读取并校验账号,在任何网络请求之前报告所有无效行
+	accounts, err := o.loadAccounts()
+	if err != nil {
+		return fmt.Errorf("failed to load accounts: %w", err)
+	}
+	o.setAccounts(accounts)
+	enabled := enabledAccounts(accounts)
+
 	// 获取代理选项
-	proxyChoice, err := o.getProxyChoice()
+	proxyChoice, err := o.proxyChoice()
 	if err != nil {
 		return fmt.Errorf("failed to get proxy choice: %w", err)
 	}
 
 	o.log(color.YellowString("Loading configuration..."))
+	o.log(color.GreenString("Profile: ") + color.WhiteString("%s (%s)", o.profileName(), o.endpoints().Auth))
+	if o.dryRun() {
+		o.log(color.MagentaString("Dry run: claims and WebSocket messages are logged but not sent"))
+	}
+	if err := o.openRecorder(); err != nil {
+		return fmt.Errorf("failed to start recording: %w", err)
+	}
+	o.startNotifier()
 
 	// 根据选择加载代理
 	if proxyChoice == 1 {
@@ -67,25 +135,37 @@ func (o *OpenLedger) Start() error {
 		}
 	}
 
-	// 读取账号
-	accounts, err := o.loadAccounts()
-	if err != nil {
-		return fmt.Errorf("failed to load accounts: %w", err)
+	o.log(color.GreenString("Account's Total: ") + color.WhiteString("%d", len(enabled)))
+	if disabled := len(accounts) - len(enabled); disabled > 0 {
+		o.log(color.YellowString("Disabled Accounts: ") + color.WhiteString("%d", disabled))
 	}
-
-	o.log(color.GreenString("Account's Total: ") + color.WhiteString("%d", len(accounts)))
 	o.printDivider()
 
 	o.log(color.GreenString("Starting all processes..."))
 
+	// 启动本地控制接口
+	if o.config.ControlAddr != "" {
+		if err := o.startControlServer(); err != nil {
+			return fmt.Errorf("failed to start control server: %w", err)
+		}
+	}
+
+	// 启用交互式面板
+	if o.config.TUI {
+		o.startDashboard()
+	}
+
 	// 创建一个通道来等待所有账号处理完成
 	done := make(chan struct{})
 
 	// 为每个账号启动处理
-	for _, account := range accounts {
-		o.wg.Add(1)
-		go o.processAccount(account, proxyChoice > 0)
+	o.reloadMutex.Lock()
+	o.useProxy = proxyChoice > 0
+	for _, account := range enabled {
+		o.startAccount(account.Address)
 	}
+	o.started = true
+	o.reloadMutex.Unlock()
 
 	// 在新的goroutine中等待所有账号处理完成
 	go func() {
@@ -99,44 +179,164 @@ func (o *OpenLedger) Start() error {
 }
 
 func (o *OpenLedger) Stop() {
-	o.running = false
+	o.running.Store(false)
+	for _, account := range o.accountList() {
+		rt := o.runtime(account)
+		o.transition(rt, StateStopped, "bot stopped")
+		rt.stop()
+	}
+	if o.control != nil {
+		o.control.close()
+	}
+	if o.dashboard != nil {
+		o.dashboard.close()
+	}
 	o.wg.Wait()
+	if o.notifier != nil {
+		o.notifier.close()
+	}
+	if o.recorder != nil {
+		o.recorder.Close()
+	}
 	if o.logger != nil {
 		o.logger.Close()
 	}
 }
 
-// 实现各种辅助方法
-func (o *OpenLedger) clearTerminal() {
-	fmt.Print("\033[H\033[2J")
+// Reload 应用新配置并重新读取账号列表,启动新增账号、停止已移除的账号,其余账号不受影响
+// 配置和账号文件都校验通过后才会生效,任一项有误时保持原配置不变
+// 切换环境时所有运行中的账号会重启以连接新的地址,代理来源的修改需要重启程序
+func (o *OpenLedger) Reload(config Config) error {
+	o.reloadMutex.Lock()
+	defer o.reloadMutex.Unlock()
+
+	if !o.started {
+		return fmt.Errorf("bot has not started yet")
+	}
+
+	o.log(color.YellowString("Reloading configuration..."))
+	config = config.withDefaults()
+	if err := config.Validate(); err != nil {
+		o.log(color.RedString("Reload failed: %v", err))
+		return err
+	}
+
+	current := o.settings()
+	if config.Proxy != current.Proxy {
+		err := fmt.Errorf("proxy source changed from %q to %q: restart required", current.Proxy, config.Proxy)
+		o.log(color.RedString("Reload failed: %v", err))
+		return err
+	}
+
+	accounts, err := o.readAccounts(config.accountsFile())
+	if err != nil {
+		o.log(color.RedString("Reload failed: %v", err))
+		return fmt.Errorf("failed to load accounts: %w", err)
+	}
+
+	switched := config.Profile != current.Profile || !sameProfiles(config.Profiles, current.Profiles)
+	o.applyConfig(config)
+
+	// 按不区分大小写的地址比较,只改了地址大小写的账号视为同一账号
+	previous := make(map[string]Account)
+	for _, address := range o.accountList() {
+		previous[accountKey(address)] = o.accountConfig(address)
+	}
+	o.setAccounts(accounts)
+
+	wanted := make(map[string]Account)
+	for _, account := range enabledAccounts(accounts) {
+		wanted[accountKey(account.Address)] = account
+	}
+
+	removed, restarted := 0, 0
+	for key, old := range previous {
+		account, ok := wanted[key]
+		if !ok {
+			o.stopAccount(old.Address)
+			removed++
+			continue
+		}
+		// 任务配置变化或切换环境时账号需要重启,只改别名和标签的账号不受影响
+		if switched || account.Tasks != old.Tasks {
+			o.stopAccount(old.Address)
+			o.startAccount(account.Address)
+			restarted++
+		}
+	}
+
+	added := 0
+	for _, account := range enabledAccounts(accounts) {
+		if _, ok := previous[accountKey(account.Address)]; !ok {
+			o.startAccount(account.Address)
+			added++
+		}
+	}
+
+	o.log(color.GreenString("Accounts reloaded: ") + color.WhiteString("%d added, %d removed, %d restarted, %d unchanged",
+		added, removed, restarted, len(wanted)-added-restarted))
+	return nil
+}
+
+// enabledAccounts 过滤出启用的账号
+func enabledAccounts(accounts []Account) []Account {
+	var result []Account
+	for _, account := range accounts {
+		if account.Enabled {
+			result = append(result, account)
+		}
+	}
+	return result
 }
 
-func (o *OpenLedger) welcome() {
-	green := color.New(color.FgGreen, color.Bold)
-	blue := color.New(color.FgBlue, color.Bold)
-	yellow := color.New(color.FgYellow, color.Bold)
-
-	fmt.Println()
-	green.Print("Auto Ping ")
-	blue.Println("Open Ledger - BOT")
-	fmt.Println()
-	green.Print("Rey? ")
-	yellow.Println("<INI WATERMARK>")
-	fmt.Println()
+// startAccount 为账号登记运行时状态并启动处理goroutine
+// 登记在返回前完成,紧接着的重新加载或停止能看到该账号,不会重复启动
+func (o *OpenLedger) startAccount(account string) {
+	rt := o.registerRuntime(account)
+	o.wg.Add(1)
+	go o.processAccount(rt, o.useProxy)
 }
 
-func (o *OpenLedger) log(message string) {
-	if o.logger != nil {
-		o.logger.Log(message)
+// stopAccount 优雅停止账号的所有任务
+func (o *OpenLedger) stopAccount(account string) {
+	rt := o.runtime(account)
+	o.transition(rt, StateStopped, "account removed")
+	o.unregisterRuntime(rt)
+	rt.stop()
+
+	o.log(fmt.Sprintf("%s Account %s - Stopping",
+		color.YellowString("!"),
+		color.WhiteString(o.displayName(account))))
+}
+
+// startDashboard 启动交互式面板,非终端输出时保持逐行日志
+func (o *OpenLedger) startDashboard() {
+	out, ok := o.output.(*os.File)
+	if !ok || !isTerminal(os.Stdin) || !isTerminal(out) {
+		o.log(color.YellowString("Output is not a terminal, falling back to line logging"))
+		return
 	}
+
+	d := newDashboard(o, os.Stdin, out)
+	if err := d.start(); err != nil {
+		o.log(color.YellowString("Failed to start dashboard: %v", err))
+		return
+	}
+	o.dashboard = d
 }
 
-func (o *OpenLedger) generateID() string {
-	return uuid.New().String()
+// SetJSONLogs 日志以每行一个JSON对象写入w
+func (o *OpenLedger) SetJSONLogs(w io.Writer) {
+	if o.logger != nil {
+		o.logger.SetJSONOutput(w)
+	}
 }
 
-func (o *OpenLedger) generateWorkerID(account string) string {
-	return base64.StdEncoding.EncodeToString([]byte(account))
+// log 记录日志,接口错误等内容中出现的钱包地址和Worker ID替换为显示名称
+func (o *OpenLedger) log(message string) {
+	if o.logger != nil {
+		o.logger.Log(o.maskAddresses(message))
+	}
 }
 
 func (o *OpenLedger) hideAccount(account string) string {
@@ -1,131 +1,94 @@
 package bot
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"net/http"
 	"time"
 
 	"github.com/fatih/color"
+
+	"openledger/pkg/openledger"
 )
 
 // getCheckinDetails 获取签到详情
-func (o *OpenLedger) getCheckinDetails(account, token, proxy string) (*CheckinDetailsResponse, error) {
-	url := "https://rewardstn.openledger.xyz/api/v1/claim_details"
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	if proxy != "" {
-		if transport, err := o.getProxyClient(proxy); err == nil {
-			client.Transport = transport
-		}
-	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == 401 {
-		newToken, err := o.renewToken(account, proxy)
-		if err != nil {
-			return nil, fmt.Errorf("token renewal failed: %w", err)
-		}
-		return o.getCheckinDetails(account, newToken, proxy)
-	}
-
-	var result CheckinDetailsResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	return &result, nil
+func (o *OpenLedger) getCheckinDetails(account, proxy string) (*openledger.CheckinDetailsResponse, error) {
+	var result *openledger.CheckinDetailsResponse
+	err := o.callAPI(account, proxy, func(client *openledger.Client, token string) (err error) {
+		result, err = client.CheckinDetails(context.Background(), token)
+		return err
+	})
+	return result, err
 }
 
 // claimCheckin 领取签到奖励
-func (o *OpenLedger) claimCheckin(account, token, proxy string) (*ClaimCheckinResponse, error) {
-	url := "https://rewardstn.openledger.xyz/api/v1/claim_reward"
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+func (o *OpenLedger) claimCheckin(account, proxy string) (*openledger.ClaimCheckinResponse, error) {
+	if o.dryRun() {
+		o.logDryRun(account, "claim the daily check-in")
+		return nil, errDryRun
 	}
 
-	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	if proxy != "" {
-		if transport, err := o.getProxyClient(proxy); err == nil {
-			client.Transport = transport
-		}
-	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == 401 {
-		newToken, err := o.renewToken(account, proxy)
-		if err != nil {
-			return nil, fmt.Errorf("token renewal failed: %w", err)
-		}
-		return o.claimCheckin(account, newToken, proxy)
-	}
-
-	var result ClaimCheckinResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	return &result, nil
+	var result *openledger.ClaimCheckinResponse
+	err := o.callAPI(account, proxy, func(client *openledger.Client, token string) (err error) {
+		result, err = client.ClaimCheckin(context.Background(), token)
+		return err
+	})
+	return result, err
 }
 
 // processCheckin 处理签到
-func (o *OpenLedger) processCheckin(account, token, proxy string, errChan chan<- error) {
-	for o.running {
-		details, err := o.getCheckinDetails(account, token, proxy)
+func (o *OpenLedger) processCheckin(account, proxy string, errChan chan<- error) {
+	rt := o.runtime(account)
+	for o.active(rt) {
+		o.waitIfPaused(rt)
+
+		details, err := o.getCheckinDetails(account, proxy)
 		if err != nil {
+			rt.setCheckinState(checkinStateError)
 			errChan <- fmt.Errorf("get checkin details failed: %w", err)
-			time.Sleep(time.Minute)
+			rt.sleep(time.Minute, rt.checkinNow)
 			continue
 		}
 
 		if !details.Data.Claimed {
-			claim, err := o.claimCheckin(account, token, proxy)
+			claim, err := o.claimCheckin(account, proxy)
+			if errors.Is(err, errDryRun) {
+				rt.setCheckinState(checkinStateUnclaimed)
+				rt.setCheckinPoints(Decimal{})
+				o.log(fmt.Sprintf("%s Account: %s - Check-In: Would Claim - Reward: %s PTS",
+					color.CyanString("["),
+					color.WhiteString(o.displayName(account)),
+					details.Data.DailyPoint.StringFixed(2)))
+				rt.sleep(time.Duration(o.settings().CheckinInterval), rt.checkinNow)
+				continue
+			}
 			if err != nil {
+				rt.setCheckinState(checkinStateError)
 				errChan <- fmt.Errorf("claim checkin failed: %w", err)
-				time.Sleep(time.Minute)
+				rt.sleep(time.Minute, rt.checkinNow)
 				continue
 			}
 
 			if claim.Data.Claimed {
-				o.log(fmt.Sprintf("%s Account: %s - Check-In: Is Claimed - Reward: %.2f PTS",
-					color.CyanString("["),
-					color.WhiteString(o.hideAccount(account)),
-					details.Data.DailyPoint))
+				rt.setCheckinState(checkinStateClaimed)
+				rt.setCheckinPoints(details.Data.DailyPoint)
+				o.emit(account, &CheckinClaimed{Points: details.Data.DailyPoint})
 			} else {
+				rt.setCheckinState(checkinStateUnclaimed)
+				rt.setCheckinPoints(Decimal{})
 				o.log(fmt.Sprintf("%s Account: %s - Check-In: Isn't Claimed",
 					color.CyanString("["),
-					color.WhiteString(o.hideAccount(account))))
+					color.WhiteString(o.displayName(account))))
 			}
 		} else {
+			rt.setCheckinState(checkinStateClaimed)
+			rt.setCheckinPoints(details.Data.DailyPoint)
 			o.log(fmt.Sprintf("%s Account: %s - Check-In: Is Already Claimed",
 				color.CyanString("["),
-				color.WhiteString(o.hideAccount(account))))
+				color.WhiteString(o.displayName(account))))
 		}
 
-		// 每24小时检查一次
-		time.Sleep(24 * time.Hour)
+		// 按配置的间隔检查(默认24小时),收到控制命令时立即执行
+		rt.sleep(time.Duration(o.settings().CheckinInterval), rt.checkinNow)
 	}
 }
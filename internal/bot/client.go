@@ -0,0 +1,111 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/gorilla/websocket"
+
+	"openledger/pkg/openledger"
+)
+
+// accountLogger 将客户端的日志输出到账号日志
+type accountLogger struct {
+	o       *OpenLedger
+	account string
+}
+
+func (l accountLogger) Printf(format string, v ...interface{}) {
+	l.o.log(fmt.Sprintf("%s Account %s - %s",
+		color.YellowString("!"),
+		color.WhiteString(l.o.displayName(l.account)),
+		fmt.Sprintf(format, v...)))
+}
+
+// apiClient 创建账号使用的接口客户端,包含代理、录制和响应结构检查
+func (o *OpenLedger) apiClient(account, proxy string) (*openledger.Client, error) {
+	var transport http.RoundTripper
+	dialer := &websocket.Dialer{HandshakeTimeout: 45 * time.Second}
+	if proxy != "" {
+		proxyTransport, err := o.getProxyClient(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set proxy: %w", err)
+		}
+		transport = proxyTransport
+		// HTTP代理通过 CONNECT 建立WebSocket连接,SOCKS代理由传输层的 DialContext 拨号
+		dialer.Proxy = proxyTransport.Proxy
+		dialer.NetDialContext = proxyTransport.DialContext
+	}
+
+	return openledger.New(
+		openledger.WithHTTPClient(&http.Client{
+			Timeout:   30 * time.Second,
+			Transport: o.httpTransport(account, transport),
+		}),
+		openledger.WithDialer(dialer),
+		openledger.WithEndpoints(o.endpoints()),
+		openledger.WithExtensionID(o.extensionID),
+		openledger.WithUserAgent(o.generateUserAgent()),
+		openledger.WithLogger(accountLogger{o: o, account: account}),
+		openledger.WithResponseHook(o.checkResponse),
+		openledger.WithFrameHook(func(direction string, data []byte) {
+			o.recordFrame(account, direction, data)
+		}),
+	), nil
+}
+
+// accountClient 返回账号运行时共用的接口客户端和令牌管理器,首次使用或代理变化时创建
+func (o *OpenLedger) accountClient(account, proxy string) (*openledger.Client, *openledger.TokenManager, error) {
+	rt := o.runtime(account)
+	rt.mu.Lock()
+	client, tokens, clientProxy := rt.client, rt.tokens, rt.clientProxy
+	rt.mu.Unlock()
+	if client != nil && clientProxy == proxy {
+		return client, tokens, nil
+	}
+
+	client, err := o.apiClient(account, proxy)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	// 并发创建时保留先登记的客户端
+	if rt.client != nil && rt.clientProxy == proxy {
+		return rt.client, rt.tokens, nil
+	}
+	// 更换客户端时沿用已有的令牌
+	tokens = client.NewTokenManager(account)
+	if rt.tokens != nil {
+		tokens.Set(rt.tokens.Current())
+	}
+	rt.client, rt.tokens, rt.clientProxy = client, tokens, proxy
+	return client, tokens, nil
+}
+
+// callAPI 使用令牌管理器中的令牌调用接口,返回401时更新令牌后重试一次
+func (o *OpenLedger) callAPI(account, proxy string, call func(client *openledger.Client, token string) error) error {
+	client, _, err := o.accountClient(account, proxy)
+	if err != nil {
+		return err
+	}
+	token, err := o.accessToken(account, proxy)
+	if err != nil {
+		return err
+	}
+
+	err = call(client, token)
+	if !errors.Is(err, openledger.ErrUnauthorized) {
+		return err
+	}
+
+	newToken, err := o.renewToken(account, proxy)
+	if err != nil {
+		return fmt.Errorf("token renewal failed: %w", err)
+	}
+	return call(client, newToken)
+}
@@ -0,0 +1,402 @@
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"openledger/pkg/openledger"
+)
+
+// 一次性子命令
+const (
+	CommandRun     = "run"
+	CommandStatus  = "status"
+	CommandCheckin = "checkin"
+	CommandTiers   = "tiers"
+	CommandToken   = "token"
+)
+
+// CommandOptions 一次性子命令的参数
+type CommandOptions struct {
+	// Account 只处理指定账号(序号、地址或别名),为空时处理所有启用的账号
+	Account string
+	// JSON 以JSON格式输出结果
+	JSON bool
+	// Claim tiers 命令同时领取可领取的等级奖励
+	Claim bool
+}
+
+// StatusResult status 命令的结果
+type StatusResult struct {
+	Account    string   `json:"account"`
+	TotalPoint *Decimal `json:"totalPoint"`
+	Earnings   Earnings `json:"earnings"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// CheckinResult checkin 命令的结果
+type CheckinResult struct {
+	Account        string  `json:"account"`
+	Claimed        bool    `json:"claimed"`
+	AlreadyClaimed bool    `json:"alreadyClaimed"`
+	WouldClaim     bool    `json:"wouldClaim,omitempty"`
+	DailyPoint     Decimal `json:"dailyPoint"`
+	Error          string  `json:"error,omitempty"`
+}
+
+// TierResult tiers 命令的结果
+type TierResult struct {
+	Account string       `json:"account"`
+	Tiers   []TierStatus `json:"tiers"`
+	Error   string       `json:"error,omitempty"`
+}
+
+// TierStatus 单个等级的领取状态
+type TierStatus struct {
+	ID          int     `json:"id"`
+	Name        string  `json:"name"`
+	Value       Decimal `json:"value"`
+	Claimed     bool    `json:"claimed"`
+	JustClaimed bool    `json:"justClaimed,omitempty"`
+	WouldClaim  bool    `json:"wouldClaim,omitempty"`
+}
+
+// TokenResult token 命令的结果,只包含过期时间而不包含令牌本身
+type TokenResult struct {
+	Account   string     `json:"account"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	ExpiresIn string     `json:"expiresIn,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// RunCommand 对一个或所有账号执行一次性子命令并输出结果,有账号失败时返回错误
+func (o *OpenLedger) RunCommand(command string, options CommandOptions, out io.Writer) error {
+	// 日志输出到标准错误,保持标准输出只有结果
+	if o.logger != nil {
+		o.logger.SetOutput(func(line string) {
+			fmt.Fprintln(os.Stderr, line)
+		})
+	}
+
+	accounts, err := o.loadAccounts()
+	if err != nil {
+		return fmt.Errorf("failed to load accounts: %w", err)
+	}
+	o.setAccounts(accounts)
+
+	accounts, err = selectAccounts(enabledAccounts(accounts), options.Account)
+	if err != nil {
+		return err
+	}
+	// 登记运行时状态,同一账号的多个请求共用一个客户端
+	for _, account := range accounts {
+		o.registerRuntime(account.Address)
+	}
+
+	if err := o.loadCommandProxies(); err != nil {
+		return err
+	}
+
+	if err := o.openRecorder(); err != nil {
+		return fmt.Errorf("failed to start recording: %w", err)
+	}
+
+	var results interface{}
+	failed := 0
+	switch command {
+	case CommandStatus:
+		list := make([]StatusResult, 0, len(accounts))
+		for _, account := range accounts {
+			result := o.commandStatus(account.Address)
+			if result.Error != "" {
+				failed++
+			}
+			list = append(list, result)
+		}
+		results = list
+	case CommandCheckin:
+		list := make([]CheckinResult, 0, len(accounts))
+		for _, account := range accounts {
+			result := o.commandCheckin(account.Address)
+			if result.Error != "" {
+				failed++
+			}
+			list = append(list, result)
+		}
+		results = list
+	case CommandTiers:
+		list := make([]TierResult, 0, len(accounts))
+		for _, account := range accounts {
+			result := o.commandTiers(account.Address, options.Claim)
+			if result.Error != "" {
+				failed++
+			}
+			list = append(list, result)
+		}
+		results = list
+	case CommandToken:
+		list := make([]TokenResult, 0, len(accounts))
+		for _, account := range accounts {
+			result := o.commandToken(account.Address)
+			if result.Error != "" {
+				failed++
+			}
+			list = append(list, result)
+		}
+		results = list
+	default:
+		return fmt.Errorf("unknown command: %s", command)
+	}
+
+	if options.JSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(results); err != nil {
+			return err
+		}
+	} else {
+		writeTable(out, results)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d account(s) failed", failed, len(accounts))
+	}
+	return nil
+}
+
+// selectAccounts 按序号(从1开始)、地址或别名选择账号,为空时返回全部
+func selectAccounts(accounts []Account, selector string) ([]Account, error) {
+	if selector == "" {
+		return accounts, nil
+	}
+
+	if index, err := strconv.Atoi(selector); err == nil {
+		if index >= 1 && index <= len(accounts) {
+			return accounts[index-1 : index], nil
+		}
+		return nil, fmt.Errorf("account index %d out of range (1-%d)", index, len(accounts))
+	}
+
+	for _, account := range accounts {
+		if strings.EqualFold(account.Address, selector) || account.Alias == selector {
+			return []Account{account}, nil
+		}
+	}
+	return nil, fmt.Errorf("account %q not found", selector)
+}
+
+// loadCommandProxies 按配置加载代理,不交互式询问
+func (o *OpenLedger) loadCommandProxies() error {
+	switch source := o.settings().Proxy; source {
+	case "", "none":
+		o.useProxy = false
+		return nil
+	case "auto":
+		o.useProxy = true
+		return o.loadAutoProxies()
+	case "manual":
+		o.useProxy = true
+		return o.loadManualProxies()
+	default:
+		return fmt.Errorf("unknown proxy source %q (expected auto, manual or none)", source)
+	}
+}
+
+// commandProxy 为一次性命令选择代理
+func (o *OpenLedger) commandProxy() string {
+	if !o.useProxy {
+		return ""
+	}
+	return o.getNextProxy()
+}
+
+func (o *OpenLedger) commandStatus(account string) StatusResult {
+	result := StatusResult{Account: o.displayName(account)}
+	proxy := o.commandProxy()
+
+	if _, err := o.generateToken(account, proxy); err != nil {
+		result.Error = redactError(err)
+		return result
+	}
+
+	earnings, err := o.sampleEarnings(account, proxy)
+	errs := []error{err}
+
+	if details, err := o.getCheckinDetails(account, proxy); err != nil {
+		errs = append(errs, fmt.Errorf("get checkin details failed: %w", err))
+	} else if details.Data.Claimed {
+		earnings.Checkin = known(details.Data.DailyPoint)
+	} else {
+		earnings.Checkin = known(Decimal{})
+	}
+
+	if tiers, err := o.getTierDetails(account, proxy); err != nil {
+		errs = append(errs, fmt.Errorf("get tier details failed: %w", err))
+	} else {
+		earnings.Tier = known(claimedTierPoints(tiers.Data.TierDetails))
+	}
+
+	result.Earnings = earnings
+	result.TotalPoint = earnings.Total()
+	if err := errors.Join(errs...); err != nil {
+		result.Error = strings.ReplaceAll(redactError(err), "\n", "; ")
+	}
+	return result
+}
+
+func (o *OpenLedger) commandCheckin(account string) CheckinResult {
+	result := CheckinResult{Account: o.displayName(account)}
+	proxy := o.commandProxy()
+
+	if _, err := o.generateToken(account, proxy); err != nil {
+		result.Error = redactError(err)
+		return result
+	}
+
+	details, err := o.getCheckinDetails(account, proxy)
+	if err != nil {
+		result.Error = redactError(err)
+		return result
+	}
+	result.DailyPoint = details.Data.DailyPoint
+
+	if details.Data.Claimed {
+		result.AlreadyClaimed = true
+		return result
+	}
+
+	claim, err := o.claimCheckin(account, proxy)
+	if errors.Is(err, errDryRun) {
+		result.WouldClaim = true
+		return result
+	}
+	if err != nil {
+		result.Error = redactError(err)
+		return result
+	}
+	result.Claimed = claim.Data.Claimed
+	return result
+}
+
+func (o *OpenLedger) commandTiers(account string, claim bool) TierResult {
+	result := TierResult{Account: o.displayName(account), Tiers: []TierStatus{}}
+	proxy := o.commandProxy()
+
+	if _, err := o.generateToken(account, proxy); err != nil {
+		result.Error = redactError(err)
+		return result
+	}
+
+	tiers, err := o.getTierDetails(account, proxy)
+	if err != nil {
+		result.Error = redactError(err)
+		return result
+	}
+
+	for _, tier := range tiers.Data.TierDetails {
+		status := TierStatus{
+			ID:      tier.ID,
+			Name:    tier.Name,
+			Value:   tier.Value,
+			Claimed: tier.ClaimStatus,
+		}
+
+		if claim && !tier.ClaimStatus {
+			response, err := o.claimTier(account, proxy, tier.ID)
+			if errors.Is(err, errDryRun) {
+				status.WouldClaim = true
+			} else if err != nil {
+				result.Error = redactError(err)
+			} else if response != nil && response.Status == "SUCCESS" {
+				status.Claimed = true
+				status.JustClaimed = true
+			}
+		}
+
+		result.Tiers = append(result.Tiers, status)
+	}
+	return result
+}
+
+func (o *OpenLedger) commandToken(account string) TokenResult {
+	result := TokenResult{Account: o.displayName(account)}
+
+	token, err := o.generateToken(account, o.commandProxy())
+	if err != nil {
+		result.Error = redactError(err)
+		return result
+	}
+
+	// 不是JWT的令牌无法得知过期时间,不视为失败
+	expiresAt, ok := openledger.TokenExpiry(token)
+	if !ok {
+		result.ExpiresIn = "unknown"
+		return result
+	}
+	result.ExpiresAt = &expiresAt
+	result.ExpiresIn = time.Until(expiresAt).Round(time.Second).String()
+	return result
+}
+
+// writeTable 以表格形式输出结果
+func writeTable(out io.Writer, results interface{}) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+
+	switch list := results.(type) {
+	case []StatusResult:
+		fmt.Fprintln(w, "ACCOUNT\tTOTAL PTS\tTODAY HB\tWORKER HB\tCHECK-IN PTS\tTIER PTS\tERROR")
+		for _, r := range list {
+			e := r.Earnings
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", r.Account, formatPoints(r.TotalPoint),
+				formatCount(e.TodayHeartbeats), formatCount(e.Heartbeats), formatPoints(e.Checkin), formatPoints(e.Tier), r.Error)
+		}
+	case []CheckinResult:
+		fmt.Fprintln(w, "ACCOUNT\tCHECK-IN\tDAILY PTS\tERROR")
+		for _, r := range list {
+			state := "Not Claimed"
+			if r.AlreadyClaimed {
+				state = "Already Claimed"
+			} else if r.WouldClaim {
+				state = "Would Claim"
+			} else if r.Claimed {
+				state = "Claimed"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Account, state, r.DailyPoint.StringFixed(2), r.Error)
+		}
+	case []TierResult:
+		fmt.Fprintln(w, "ACCOUNT\tTIER\tREWARD PTS\tSTATUS\tERROR")
+		for _, r := range list {
+			if len(r.Tiers) == 0 {
+				fmt.Fprintf(w, "%s\t-\t-\t-\t%s\n", r.Account, r.Error)
+			}
+			for _, tier := range r.Tiers {
+				state := "Not Claimed"
+				if tier.JustClaimed {
+					state = "Claimed Now"
+				} else if tier.WouldClaim {
+					state = "Would Claim"
+				} else if tier.Claimed {
+					state = "Claimed"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", r.Account, tier.Name, tier.Value.StringFixed(2), state, r.Error)
+			}
+		}
+	case []TokenResult:
+		fmt.Fprintln(w, "ACCOUNT\tEXPIRES AT\tEXPIRES IN\tERROR")
+		for _, r := range list {
+			expiresAt := "-"
+			if r.ExpiresAt != nil {
+				expiresAt = r.ExpiresAt.Format("2006-01-02 15:04:05 MST")
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Account, expiresAt, r.ExpiresIn, r.Error)
+		}
+	}
+}
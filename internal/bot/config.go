@@ -0,0 +1,220 @@
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Config 运行配置
+type Config struct {
+	// TUI 是否启用交互式终端面板（非终端输出时自动回退到逐行日志）
+	TUI bool `json:"tui"`
+
+	// ControlAddr 本地控制接口的监听地址,为空时不启动
+	ControlAddr string `json:"controlAddr"`
+	// ControlToken 控制接口的访问令牌,为空时自动生成
+	ControlToken string `json:"controlToken"`
+
+	// Profile 使用的环境名称(testnet、mainnet、local 或 Profiles 中自定义的名称),默认 testnet
+	Profile string `json:"profile"`
+	// Profiles 自定义环境,与内置环境同名时覆盖内置环境
+	Profiles map[string]Endpoints `json:"profiles"`
+
+	// AccountsFile 账号文件路径,默认为 accounts.txt
+	AccountsFile string `json:"accountsFile"`
+
+	// DryRun 演练模式,只执行只读请求,签到、等级领取和WebSocket注册、心跳、任务确认只记录日志
+	DryRun bool `json:"dryRun"`
+
+	// Record 录制目录,不为空时将脱敏后的HTTP请求和WebSocket消息按账号写入该目录,重启后生效
+	Record string `json:"record"`
+
+	// StrictDecode 报告接口响应中未在 pkg/openledger/types.go 中定义的字段,缺失字段和结构变化始终会告警
+	StrictDecode bool `json:"strictDecode"`
+
+	// Proxy 代理来源: auto、manual 或 none,为空时运行前交互式询问,一次性命令视为 none
+	Proxy string `json:"proxy"`
+
+	// AccountMask 账号在日志、面板、通知、指标和接口中的显示方式: partial(默认,地址前后6位)、alias(只显示别名或序号)或 pseudonym(HMAC假名),设置了别名的账号始终显示别名
+	AccountMask string `json:"accountMask"`
+	// PseudonymKey pseudonym 模式的密钥,也可以通过环境变量 OPENLEDGER_PSEUDONYM_KEY 指定,为空时自动生成并保存到 pseudonym_key.txt
+	PseudonymKey string `json:"pseudonymKey"`
+
+	// Notify 重要事件的通知渠道,修改后重新加载即可生效
+	Notify NotifyConfig `json:"notify"`
+
+	// 各任务的执行间隔,重新加载后生效
+	EarningInterval   Duration `json:"earningInterval"`
+	CheckinInterval   Duration `json:"checkinInterval"`
+	TierInterval      Duration `json:"tierInterval"`
+	HeartbeatInterval Duration `json:"heartbeatInterval"`
+	// ReconcileInterval 心跳对账的间隔
+	ReconcileInterval Duration `json:"reconcileInterval"`
+}
+
+// Duration 支持 "10m"、"24h" 形式的JSON时长
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"10m\": %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	if parsed <= 0 {
+		return fmt.Errorf("duration must be positive, got %s", s)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// DefaultConfig 返回默认配置
+func DefaultConfig() Config {
+	return Config{
+		EarningInterval:   Duration(10 * time.Minute),
+		CheckinInterval:   Duration(24 * time.Hour),
+		TierInterval:      Duration(24 * time.Hour),
+		HeartbeatInterval: Duration(30 * time.Second),
+		ReconcileInterval: Duration(time.Hour),
+	}
+}
+
+// withDefaults 未设置(为0)的任务间隔使用默认值,直接构造的 Config{} 也可以安全使用
+func (c Config) withDefaults() Config {
+	defaults := DefaultConfig()
+	for _, field := range []struct{ value, fallback *Duration }{
+		{&c.EarningInterval, &defaults.EarningInterval},
+		{&c.CheckinInterval, &defaults.CheckinInterval},
+		{&c.TierInterval, &defaults.TierInterval},
+		{&c.HeartbeatInterval, &defaults.HeartbeatInterval},
+		{&c.ReconcileInterval, &defaults.ReconcileInterval},
+	} {
+		if *field.value == 0 {
+			*field.value = *field.fallback
+		}
+	}
+	return c
+}
+
+// LoadConfig 在默认配置基础上读取配置文件,文件不存在时返回默认配置
+func LoadConfig(path string) (Config, error) {
+	config := DefaultConfig()
+	if path == "" {
+		return config, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return config, nil
+	}
+	if err != nil {
+		return config, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return config, nil
+}
+
+// Validate 校验配置中的环境、账号显示方式、任务间隔和通知设置,为0的间隔视为未设置
+func (c Config) Validate() error {
+	if _, err := c.ResolveEndpoints(); err != nil {
+		return err
+	}
+	if err := validateAccountMask(c.AccountMask); err != nil {
+		return err
+	}
+	for name, interval := range map[string]Duration{
+		"earningInterval":   c.EarningInterval,
+		"checkinInterval":   c.CheckinInterval,
+		"tierInterval":      c.TierInterval,
+		"heartbeatInterval": c.HeartbeatInterval,
+		"reconcileInterval": c.ReconcileInterval,
+	} {
+		if interval < 0 {
+			return fmt.Errorf("%s must be positive, got %s", name, time.Duration(interval))
+		}
+	}
+	return c.Notify.validate()
+}
+
+// settings 获取当前生效的配置
+func (o *OpenLedger) settings() Config {
+	o.configMutex.Lock()
+	defer o.configMutex.Unlock()
+	return o.config
+}
+
+// applyConfig 应用新配置,运行中无法修改的项目保持原值
+func (o *OpenLedger) applyConfig(config Config) {
+	o.configMutex.Lock()
+	old := o.config
+	restartNeeded := config.TUI != old.TUI || config.ControlAddr != old.ControlAddr
+	config.TUI = old.TUI
+	config.ControlAddr = old.ControlAddr
+	o.config = config
+	o.configMutex.Unlock()
+
+	if restartNeeded {
+		o.log(color.YellowString("TUI and control address changes take effect after restart"))
+	}
+
+	if config.Profile != old.Profile || !sameProfiles(config.Profiles, old.Profiles) {
+		endpoints := o.endpoints()
+		o.log(color.GreenString("Profile switched to ") + color.WhiteString("%s (%s)", config.Profile, endpoints.Auth))
+	}
+
+	if o.control != nil && config.ControlToken != "" && config.ControlToken != old.ControlToken {
+		o.control.setToken(config.ControlToken)
+		o.log(color.GreenString("Control token updated"))
+	}
+
+	if config.DryRun != old.DryRun {
+		if config.DryRun {
+			o.log(color.MagentaString("Dry run enabled: claims and WebSocket messages are logged but not sent"))
+		} else {
+			o.log(color.GreenString("Dry run disabled"))
+		}
+	}
+
+	if config.AccountMask != old.AccountMask || config.PseudonymKey != old.PseudonymKey {
+		mode := config.AccountMask
+		if mode == "" {
+			mode = MaskPartial
+		}
+		o.log(color.GreenString("Account masking: ") + color.WhiteString(mode))
+	}
+
+	if o.notifier != nil && !reflect.DeepEqual(config.Notify, old.Notify) {
+		o.notifier.update(config.Notify)
+		o.log(color.GreenString("Notifications updated: ") + color.WhiteString("%d sink(s)", o.notifier.sinkCount()))
+	}
+
+	if config.EarningInterval != old.EarningInterval ||
+		config.CheckinInterval != old.CheckinInterval ||
+		config.TierInterval != old.TierInterval ||
+		config.HeartbeatInterval != old.HeartbeatInterval ||
+		config.ReconcileInterval != old.ReconcileInterval {
+		o.log(color.GreenString("Task intervals updated: ") + color.WhiteString("earning %s, check-in %s, tier %s, heartbeat %s, reconcile %s",
+			time.Duration(config.EarningInterval),
+			time.Duration(config.CheckinInterval),
+			time.Duration(config.TierInterval),
+			time.Duration(config.HeartbeatInterval),
+			time.Duration(config.ReconcileInterval)))
+	}
+}
@@ -0,0 +1,281 @@
+package bot
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// controlTokenFile 未指定控制令牌时,自动生成的令牌保存位置
+const controlTokenFile = "control_token.txt"
+
+// 控制命令
+const (
+	commandPause     = "pause"
+	commandResume    = "resume"
+	commandReconnect = "reconnect"
+	commandCheckin   = "checkin"
+	commandClaimTier = "claim-tier"
+	commandRenew     = "renew-token"
+)
+
+// controlServer 本地状态与控制接口
+type controlServer struct {
+	o      *OpenLedger
+	mu     sync.Mutex
+	token  string
+	server *http.Server
+}
+
+type accountStatus struct {
+	Index   int      `json:"index"`
+	Account string   `json:"account"`
+	Tags    []string `json:"tags,omitempty"`
+	accountSnapshot
+}
+
+type commandResponse struct {
+	Account string `json:"account"`
+	Command string `json:"command"`
+	Status  string `json:"status"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// 控制命令无法执行的原因
+var (
+	// errAccountNotRunning 账号已被重新加载移除或已停止
+	errAccountNotRunning = errors.New("account is not running")
+	// errTaskDisabled 命令对应的任务在账号配置中未启用
+	errTaskDisabled = errors.New("task is disabled for this account")
+)
+
+// startControlServer 启动本地控制接口,只允许监听回环地址
+func (o *OpenLedger) startControlServer() error {
+	addr := o.config.ControlAddr
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid control address: %w", err)
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return fmt.Errorf("control address must be a loopback address, got %s", host)
+	}
+
+	token, err := o.controlToken()
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	c := &controlServer{o: o, token: token}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", c.handleStatus)
+	mux.HandleFunc("GET /metrics", c.handleMetrics)
+	mux.HandleFunc("POST /accounts/{account}/{command}", c.handleCommand)
+	c.server = &http.Server{
+		Handler:           c.authenticate(mux),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	o.control = c
+
+	go func() {
+		if err := c.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			o.log(color.RedString("Control server stopped: %v", err))
+		}
+	}()
+
+	o.log(color.GreenString("Control server listening on ") + color.WhiteString(listener.Addr().String()))
+	return nil
+}
+
+// controlToken 获取控制令牌,未配置时生成随机令牌并写入文件
+func (o *OpenLedger) controlToken() (string, error) {
+	if o.config.ControlToken != "" {
+		return o.config.ControlToken, nil
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate control token: %w", err)
+	}
+	token := hex.EncodeToString(buf)
+
+	if err := os.WriteFile(controlTokenFile, []byte(token+"\n"), 0600); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", controlTokenFile, err)
+	}
+	o.log(color.YellowString("Control token written to %s", controlTokenFile))
+
+	return token, nil
+}
+
+// close 关闭控制接口
+func (c *controlServer) close() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c.server.Shutdown(ctx)
+}
+
+// setToken 更换访问令牌
+func (c *controlServer) setToken(token string) {
+	c.mu.Lock()
+	c.token = token
+	c.mu.Unlock()
+}
+
+func (c *controlServer) currentToken() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.token
+}
+
+// authenticate 校验Bearer令牌
+func (c *controlServer) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(c.currentToken())) != 1 {
+			writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "unauthorized"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleStatus 返回所有账号状态
+func (c *controlServer) handleStatus(w http.ResponseWriter, r *http.Request) {
+	snapshots := c.o.snapshots()
+	result := make([]accountStatus, 0, len(snapshots))
+	for i, s := range snapshots {
+		result = append(result, accountStatus{
+			Index:           i + 1,
+			Account:         c.o.displayName(s.Account),
+			Tags:            c.o.accountConfig(s.Account).Tags,
+			accountSnapshot: s,
+		})
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleCommand 把控制命令转发给账号的goroutine
+func (c *controlServer) handleCommand(w http.ResponseWriter, r *http.Request) {
+	account, ok := c.o.resolveAccount(r.PathValue("account"))
+	if !ok {
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: "account not found"})
+		return
+	}
+
+	command := r.PathValue("command")
+	if err := c.o.runCommand(account, command); err != nil {
+		status := http.StatusBadRequest
+		switch {
+		case errors.Is(err, errAccountNotRunning):
+			status = http.StatusNotFound
+		case errors.Is(err, errTaskDisabled):
+			status = http.StatusConflict
+		}
+		writeJSON(w, status, errorResponse{Error: redactError(err)})
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, commandResponse{
+		Account: c.o.displayName(account),
+		Command: command,
+		Status:  "accepted",
+	})
+}
+
+// resolveAccount 按序号(从1开始)、钱包地址或别名查找账号
+func (o *OpenLedger) resolveAccount(id string) (string, bool) {
+	accounts := o.accountList()
+
+	if index, err := strconv.Atoi(id); err == nil {
+		if index >= 1 && index <= len(accounts) {
+			return accounts[index-1], true
+		}
+		return "", false
+	}
+
+	for _, account := range accounts {
+		if strings.EqualFold(account, id) || o.accountConfig(account).Alias == id {
+			return account, true
+		}
+	}
+	return "", false
+}
+
+// runCommand 执行控制命令,账号不在运行或对应任务未启用时返回错误
+func (o *OpenLedger) runCommand(account, command string) error {
+	o.runtimeMutex.Lock()
+	rt, ok := o.runtimes[accountKey(account)]
+	o.runtimeMutex.Unlock()
+	if !ok {
+		return errAccountNotRunning
+	}
+
+	tasks := o.accountConfig(account).Tasks
+	disabled := ""
+	switch {
+	case command == commandReconnect && !tasks.WebSocket:
+		disabled = "websocket"
+	case command == commandCheckin && !tasks.Checkin:
+		disabled = "checkin"
+	case command == commandClaimTier && !tasks.Tier:
+		disabled = "tier"
+	}
+	if disabled != "" {
+		return fmt.Errorf("%s: %w", disabled, errTaskDisabled)
+	}
+
+	switch command {
+	case commandPause:
+		if !rt.isPaused() {
+			o.togglePause(account)
+		}
+		return nil
+	case commandResume:
+		if rt.isPaused() {
+			o.togglePause(account)
+		}
+		return nil
+	case commandReconnect:
+		trigger(rt.reconnect)
+	case commandCheckin:
+		trigger(rt.checkinNow)
+	case commandClaimTier:
+		trigger(rt.tierNow)
+	case commandRenew:
+		trigger(rt.renew)
+	default:
+		return fmt.Errorf("unknown command: %s", command)
+	}
+
+	o.log(fmt.Sprintf("%s Account: %s - Command received: %s",
+		color.CyanString("["),
+		color.WhiteString(o.displayName(account)),
+		command))
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,66 @@
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const (
+	controlRunning = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+	controlRemoved = "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359"
+)
+
+func TestHandleCommand(t *testing.T) {
+	o := &OpenLedger{
+		config:   DefaultConfig(),
+		logger:   &Logger{output: func(string) {}},
+		runtimes: make(map[string]*accountRuntime),
+		events:   newEventBus(),
+	}
+	running := newAccount(controlRunning)
+	running.Tasks.Checkin = false
+	o.setAccounts([]Account{running, newAccount(controlRemoved)})
+	o.registerRuntime(controlRunning)
+	// 模拟重新加载移除账号
+	o.registerRuntime(controlRemoved)
+	o.stopAccount(controlRemoved)
+
+	c := &controlServer{o: o}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /accounts/{account}/{command}", c.handleCommand)
+
+	tests := []struct {
+		name    string
+		account string
+		command string
+		status  int
+	}{
+		{"accepted", "1", commandReconnect, http.StatusAccepted},
+		{"disabled task", controlRunning, commandCheckin, http.StatusConflict},
+		{"removed account", controlRemoved, commandReconnect, http.StatusNotFound},
+		{"unknown command", "1", "explode", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/accounts/"+tt.account+"/"+tt.command, nil))
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.status != http.StatusAccepted {
+				var body errorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Error == "" {
+					t.Errorf("error response %q has no reason", w.Body)
+				}
+			}
+		})
+	}
+
+	// 解析账号后账号被移除时同样拒绝
+	if err := o.runCommand(controlRemoved, commandReconnect); !errors.Is(err, errAccountNotRunning) {
+		t.Errorf("runCommand on removed account = %v, want %v", err, errAccountNotRunning)
+	}
+}
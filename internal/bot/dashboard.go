@@ -0,0 +1,401 @@
+package bot
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
+)
+
+const (
+	// dashboardLogLimit 面板保留的日志行数
+	dashboardLogLimit = 500
+	// dashboardRefresh 面板刷新间隔
+	dashboardRefresh = time.Second
+)
+
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+// dashboard 交互式终端面板
+type dashboard struct {
+	o   *OpenLedger
+	in  *os.File
+	out *os.File
+
+	mu            sync.Mutex
+	logs          []string
+	filter        string
+	editingFilter bool
+	selected      int
+	offset        int
+
+	restore     func()
+	unsubscribe func()
+	dirty       chan struct{}
+	stop        chan struct{}
+	done        chan struct{}
+}
+
+// isTerminal 判断文件是否为交互式终端
+func isTerminal(f *os.File) bool {
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
+
+func newDashboard(o *OpenLedger, in, out *os.File) *dashboard {
+	return &dashboard{
+		o:     o,
+		in:    in,
+		out:   out,
+		dirty: make(chan struct{}, 1),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+}
+
+// start 切换到面板模式并接管日志输出
+func (d *dashboard) start() error {
+	restore, err := enterCbreakMode(d.in, d.out)
+	if err != nil {
+		return fmt.Errorf("failed to configure terminal: %w", err)
+	}
+	d.restore = restore
+
+	// 切换到备用屏幕并隐藏光标
+	fmt.Fprint(d.out, "\033[?1049h\033[?25l")
+
+	if d.o.logger != nil {
+		d.o.logger.SetOutput(d.appendLog)
+	}
+	// 状态变化时立即重绘
+	d.unsubscribe = d.o.Subscribe(func(Event) { d.markDirty() })
+
+	go d.readInput()
+	go d.renderLoop()
+	return nil
+}
+
+// close 退出面板模式并恢复终端
+func (d *dashboard) close() {
+	select {
+	case <-d.stop:
+		return
+	default:
+	}
+	close(d.stop)
+	<-d.done
+	if d.unsubscribe != nil {
+		d.unsubscribe()
+	}
+
+	if d.o.logger != nil {
+		d.o.logger.SetOutput(nil)
+	}
+
+	fmt.Fprint(d.out, "\033[?25h\033[?1049l")
+	if d.restore != nil {
+		d.restore()
+	}
+}
+
+// appendLog 追加一行日志到日志面板
+func (d *dashboard) appendLog(line string) {
+	d.mu.Lock()
+	d.logs = append(d.logs, ansiPattern.ReplaceAllString(line, ""))
+	if len(d.logs) > dashboardLogLimit {
+		d.logs = d.logs[len(d.logs)-dashboardLogLimit:]
+	}
+	d.mu.Unlock()
+	d.markDirty()
+}
+
+func (d *dashboard) markDirty() {
+	select {
+	case d.dirty <- struct{}{}:
+	default:
+	}
+}
+
+// renderLoop 定时或在有更新时重绘面板
+func (d *dashboard) renderLoop() {
+	defer close(d.done)
+
+	ticker := time.NewTicker(dashboardRefresh)
+	defer ticker.Stop()
+
+	d.render()
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-ticker.C:
+		case <-d.dirty:
+		}
+		d.render()
+	}
+}
+
+// readInput 读取按键
+func (d *dashboard) readInput() {
+	buf := make([]byte, 16)
+	for {
+		n, err := d.in.Read(buf)
+		if err != nil {
+			return
+		}
+
+		select {
+		case <-d.stop:
+			return
+		default:
+		}
+
+		d.handleKey(buf[:n])
+		d.markDirty()
+	}
+}
+
+// handleKey 处理按键: ↑/↓ 选择账号, p 暂停/恢复, / 过滤, Esc 清除过滤
+func (d *dashboard) handleKey(key []byte) {
+	d.mu.Lock()
+
+	if d.editingFilter {
+		switch {
+		case len(key) == 1 && key[0] == 27:
+			d.filter = ""
+			d.editingFilter = false
+		case len(key) == 1 && (key[0] == '\r' || key[0] == '\n'):
+			d.editingFilter = false
+		case len(key) == 1 && (key[0] == 127 || key[0] == 8):
+			if r := []rune(d.filter); len(r) > 0 {
+				d.filter = string(r[:len(r)-1])
+			}
+		case key[0] >= 32 && key[0] != 127:
+			d.filter += string(key)
+		}
+		d.selected, d.offset = 0, 0
+		d.mu.Unlock()
+		return
+	}
+
+	switch {
+	case len(key) >= 3 && key[0] == 27 && key[1] == '[' && key[2] == 'A', len(key) == 1 && key[0] == 'k':
+		if d.selected > 0 {
+			d.selected--
+		}
+	case len(key) >= 3 && key[0] == 27 && key[1] == '[' && key[2] == 'B', len(key) == 1 && key[0] == 'j':
+		d.selected++
+	case len(key) == 1 && key[0] == '/':
+		d.editingFilter = true
+	case len(key) == 1 && key[0] == 27:
+		d.filter = ""
+		d.selected, d.offset = 0, 0
+	case len(key) == 1 && key[0] == 'p':
+		rows := d.visibleAccounts(d.o.snapshots())
+		if d.selected < len(rows) {
+			account := rows[d.selected].Account
+			d.mu.Unlock()
+			d.o.togglePause(account)
+			return
+		}
+	}
+	d.mu.Unlock()
+}
+
+// matchesFilter 判断文本是否匹配过滤条件(不区分大小写)
+func (d *dashboard) matchesFilter(text string) bool {
+	if d.filter == "" {
+		return true
+	}
+	return strings.Contains(strings.ToLower(text), strings.ToLower(d.filter))
+}
+
+// visibleAccounts 返回符合过滤条件的账号
+func (d *dashboard) visibleAccounts(snapshots []accountSnapshot) []accountSnapshot {
+	if d.filter == "" {
+		return snapshots
+	}
+
+	var result []accountSnapshot
+	for _, s := range snapshots {
+		fields := []string{s.Account, d.o.displayName(s.Account), string(s.State), s.WSStatus, s.CheckinState}
+		fields = append(fields, d.o.accountConfig(s.Account).Tags...)
+		text := strings.Join(fields, " ")
+		if s.Suspended {
+			text += " suspended"
+		} else if s.Paused {
+			text += " paused"
+		}
+		if d.matchesFilter(text) {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// render 绘制整个面板
+func (d *dashboard) render() {
+	width, height, err := terminalSize(d.out)
+	if err != nil || width <= 0 || height <= 0 {
+		width, height = 120, 40
+	}
+
+	snapshots := d.o.snapshots()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	rows := d.visibleAccounts(snapshots)
+	if d.selected >= len(rows) {
+		d.selected = len(rows) - 1
+	}
+	if d.selected < 0 {
+		d.selected = 0
+	}
+
+	// 表格和日志面板的高度分配
+	available := height - 4
+	tableHeight := len(rows)
+	if maxTable := available / 2; tableHeight > maxTable {
+		tableHeight = maxTable
+	}
+	if tableHeight < 1 {
+		tableHeight = 1
+	}
+	logHeight := available - tableHeight
+	if logHeight < 0 {
+		logHeight = 0
+	}
+
+	if d.selected < d.offset {
+		d.offset = d.selected
+	}
+	if d.selected >= d.offset+tableHeight {
+		d.offset = d.selected - tableHeight + 1
+	}
+
+	paused := 0
+	for _, s := range snapshots {
+		if s.Paused {
+			paused++
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("\033[H")
+
+	title := fmt.Sprintf(" OpenLedger Bot | Accounts: %d | Paused: %d", len(snapshots), paused)
+	if d.filter != "" || d.editingFilter {
+		title += fmt.Sprintf(" | Filter: %s", d.filter)
+		if d.editingFilter {
+			title += "_"
+		}
+	}
+	d.writeLine(&b, color.New(color.FgCyan, color.Bold).Sprint(truncate(title, width)))
+
+	header := fmt.Sprintf(" %-4s %-20s %-11s %7s %14s %10s %12s %-10s %-7s",
+		"#", "ACCOUNT", "WEBSOCKET", "HB/1H", "TOTAL PTS", "Δ POLL", "TODAY HB", "CHECK-IN", "TIERS")
+	d.writeLine(&b, color.New(color.Bold).Sprint(truncate(header, width)))
+
+	for i := 0; i < tableHeight; i++ {
+		index := d.offset + i
+		if index >= len(rows) {
+			d.writeLine(&b, "")
+			continue
+		}
+		d.writeLine(&b, d.formatRow(index, rows[index], width))
+	}
+
+	d.writeLine(&b, color.CyanString(strings.Repeat("-", width)))
+
+	logs := d.logs
+	if d.filter != "" {
+		logs = nil
+		for _, line := range d.logs {
+			if d.matchesFilter(line) {
+				logs = append(logs, line)
+			}
+		}
+	}
+	if len(logs) > logHeight {
+		logs = logs[len(logs)-logHeight:]
+	}
+	for i := 0; i < logHeight; i++ {
+		if i < len(logs) {
+			d.writeLine(&b, truncate(logs[i], width))
+		} else {
+			d.writeLine(&b, "")
+		}
+	}
+
+	help := " ↑/↓ select | p pause/resume | / filter | Esc clear filter | Ctrl+C quit"
+	b.WriteString(color.New(color.FgBlack, color.BgWhite).Sprint(truncate(help, width)))
+	b.WriteString("\033[K\033[J")
+
+	fmt.Fprint(d.out, b.String())
+}
+
+// formatRow 格式化一行账号信息
+func (d *dashboard) formatRow(index int, s accountSnapshot, width int) string {
+	status := s.WSStatus
+	if s.Suspended {
+		status = wsStatusSuspended
+	} else if s.Paused {
+		status = wsStatusPaused
+	}
+
+	total, delta, today := "-", "-", "-"
+	if s.TotalPoint != nil {
+		total = s.TotalPoint.StringFixed(2)
+	}
+	if s.Delta != nil {
+		delta = formatDelta(s.Delta)
+	}
+	if s.Earnings.TodayHeartbeats != nil {
+		today = s.Earnings.TodayHeartbeats.StringFixed(0)
+	}
+
+	tiers := "-"
+	if s.TiersTotal > 0 {
+		tiers = fmt.Sprintf("%d/%d", s.TiersClaimed, s.TiersTotal)
+	}
+
+	row := fmt.Sprintf(" %-4d %-20.20s %-11s %7d %14s %10s %12s %-10s %-7s",
+		index+1, d.o.displayName(s.Account), status, s.HeartbeatsAck, total, delta, today, s.CheckinState, tiers)
+	row = truncate(row, width)
+
+	if index == d.selected {
+		return "\033[7m" + row + "\033[0m"
+	}
+
+	switch status {
+	case wsStatusRegistered, wsStatusConnected:
+		return strings.Replace(row, status, color.GreenString(status), 1)
+	case wsStatusPaused, wsStatusConnecting:
+		return strings.Replace(row, status, color.YellowString(status), 1)
+	case wsStatusClosed, wsStatusSuspended:
+		return strings.Replace(row, status, color.RedString(status), 1)
+	}
+	return row
+}
+
+func (d *dashboard) writeLine(b *strings.Builder, line string) {
+	b.WriteString(line)
+	b.WriteString("\033[K\r\n")
+}
+
+// truncate 按终端宽度截断文本
+func truncate(text string, width int) string {
+	runes := []rune(text)
+	if len(runes) <= width {
+		return text
+	}
+	if width <= 0 {
+		return ""
+	}
+	return string(runes[:width])
+}
@@ -0,0 +1,24 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/fatih/color"
+)
+
+// errDryRun 演练模式下跳过了有副作用的请求
+var errDryRun = errors.New("skipped in dry-run mode")
+
+// dryRun 是否处于演练模式,此时只执行只读请求
+func (o *OpenLedger) dryRun() bool {
+	return o.settings().DryRun
+}
+
+// logDryRun 记录演练模式下跳过的操作
+func (o *OpenLedger) logDryRun(account, action string) {
+	o.log(fmt.Sprintf("%s Account %s - Dry run: would %s",
+		color.MagentaString("~"),
+		color.WhiteString(o.displayName(account)),
+		action))
+}
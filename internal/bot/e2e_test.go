@@ -0,0 +1,222 @@
+package bot_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"openledger/internal/bot"
+	"openledger/internal/mockserver"
+)
+
+const testAddress = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+
+// syncBuffer 可以被多个goroutine同时写入的缓冲区
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// testConfig 指向mock服务器的配置,不使用代理,心跳间隔很短
+func testConfig(t *testing.T, s *mockserver.Server) bot.Config {
+	t.Helper()
+	accounts := filepath.Join(t.TempDir(), "accounts.txt")
+	if err := os.WriteFile(accounts, []byte(testAddress+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	config := bot.DefaultConfig()
+	config.Profile = "mock"
+	config.Profiles = map[string]bot.Endpoints{"mock": s.Endpoints()}
+	config.AccountsFile = accounts
+	config.Proxy = "none"
+	config.HeartbeatInterval = bot.Duration(100 * time.Millisecond)
+	return config
+}
+
+func TestBotAgainstMockServer(t *testing.T) {
+	s := mockserver.New()
+	defer s.Close()
+
+	output := &syncBuffer{}
+	o, err := bot.NewOpenLedger(testConfig(t, s), bot.WithOutput(output), bot.WithLogger(bot.NewConsoleLogger(output)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 按顺序等待的里程碑: 获取令牌 → WebSocket注册 → 心跳确认 → 签到
+	milestones := []string{"token", "registered", "heartbeat acked", "checkin claimed"}
+	reached := make(chan string, 64)
+	o.Subscribe(func(e bot.Event) {
+		var name string
+		switch e := e.(type) {
+		case *bot.TokenGenerated:
+			name = "token"
+		case *bot.WSRegistered:
+			name = "registered"
+		case *bot.HeartbeatAcked:
+			name = "heartbeat acked"
+		case *bot.CheckinClaimed:
+			if e.Account != testAddress {
+				t.Errorf("checkin claimed for %s, want %s", e.Account, testAddress)
+			}
+			name = "checkin claimed"
+		default:
+			return
+		}
+		select {
+		case reached <- name:
+		default:
+		}
+	})
+
+	done := make(chan error, 1)
+	go func() { done <- o.Start() }()
+
+	seen := make(map[string]bool)
+	deadline := time.After(10 * time.Second)
+	for len(seen) < len(milestones) {
+		select {
+		case name := <-reached:
+			seen[name] = true
+		case err := <-done:
+			t.Fatalf("Start returned early: %v\n%s", err, output)
+		case <-deadline:
+			t.Fatalf("timed out, reached %v of %v\n%s", seen, milestones, output)
+		}
+	}
+
+	o.Stop()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Start: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Start did not return after Stop")
+	}
+
+	if count := s.Count(mockserver.EndpointGenerateToken); count < 1 {
+		t.Errorf("generate_token called %d times, want at least 1", count)
+	}
+	state := s.State()
+	if state.HeartbeatCount < 1 {
+		t.Errorf("server received %d heartbeats, want at least 1", state.HeartbeatCount)
+	}
+	if !state.CheckinClaimed {
+		t.Error("checkin was not claimed on the server")
+	}
+}
+
+// startBot 启动机器人,返回停止函数,停止时检查 Start 的返回值
+func startBot(t *testing.T, config bot.Config, output *syncBuffer, subscribe func(e bot.Event)) func() {
+	t.Helper()
+	o, err := bot.NewOpenLedger(config, bot.WithOutput(output), bot.WithLogger(bot.NewConsoleLogger(output)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.Subscribe(subscribe)
+
+	done := make(chan error, 1)
+	go func() { done <- o.Start() }()
+	return func() {
+		o.Stop()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("Start: %v", err)
+			}
+		case <-time.After(10 * time.Second):
+			t.Error("Start did not return after Stop")
+		}
+	}
+}
+
+func TestHandshakeFailureDegrades(t *testing.T) {
+	s := mockserver.New()
+	defer s.Close()
+	s.Inject(mockserver.EndpointWebSocket, mockserver.FaultServerError)
+
+	output := &syncBuffer{}
+	states := make(chan *bot.StateChanged, 64)
+	stop := startBot(t, testConfig(t, s), output, func(e bot.Event) {
+		if e, ok := e.(*bot.StateChanged); ok {
+			states <- e
+		}
+	})
+	defer stop()
+
+	// 握手失败后账号应从 Connecting 进入 Degraded,而不是停留在 Connecting
+	deadline := time.After(10 * time.Second)
+	for {
+		select {
+		case e := <-states:
+			if e.To != bot.StateDegraded {
+				continue
+			}
+			if e.From != bot.StateConnecting {
+				t.Errorf("degraded from %s, want %s", e.From, bot.StateConnecting)
+			}
+			return
+		case <-deadline:
+			t.Fatalf("account never became degraded\n%s", output)
+		}
+	}
+}
+
+func TestServiceRestartReconnectsAfterDelay(t *testing.T) {
+	s := mockserver.New()
+	defer s.Close()
+
+	output := &syncBuffer{}
+	connected := make(chan time.Time, 64)
+	closed := make(chan time.Time, 64)
+	stop := startBot(t, testConfig(t, s), output, func(e bot.Event) {
+		switch e.(type) {
+		case *bot.WSRegistered:
+			connected <- time.Now()
+		case *bot.WSClosed:
+			closed <- time.Now()
+		}
+	})
+	defer stop()
+
+	wait := func(ch chan time.Time, what string) time.Time {
+		t.Helper()
+		select {
+		case at := <-ch:
+			return at
+		case <-time.After(10 * time.Second):
+			t.Fatalf("timed out waiting for %s\n%s", what, output)
+		}
+		return time.Time{}
+	}
+
+	// 连续两次服务重启关闭,重连间隔应至少为1秒和2秒
+	wait(connected, "first registration")
+	for _, min := range []time.Duration{time.Second, 2 * time.Second} {
+		s.CloseWebSocket(1012, "service restart")
+		closedAt := wait(closed, "close")
+		if delay := wait(connected, "reconnect").Sub(closedAt); delay < min {
+			t.Errorf("reconnected %s after service restart, want at least %s", delay, min)
+		}
+	}
+	if count := s.Count(mockserver.EndpointWebSocket); count != 3 {
+		t.Errorf("websocket dialed %d times, want 3", count)
+	}
+}
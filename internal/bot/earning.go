@@ -1,200 +1,180 @@
 package bot
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"net/http"
-	"strconv"
-	"strings"
 	"time"
 
-	"github.com/fatih/color"
+	"openledger/pkg/openledger"
 )
 
-// getUserReward 获取用户奖励
-func (o *OpenLedger) getUserReward(account, token, proxy string) (float64, error) {
-	url := "https://rewardstn.openledger.xyz/api/v1/reward"
+// Decimal 积分使用的精确十进制数
+type Decimal = openledger.Decimal
+
+// Earnings 账号的积分明细,查询失败或尚未查询到的部分为nil,显示为 unknown
+type Earnings struct {
+	// Base 累计积分(reward 接口的 totalPoint),即账号的积分余额
+	Base *Decimal `json:"base"`
+	// TodayHeartbeats 今日心跳次数(reward_realtime 接口的 total_heartbeats),不是积分
+	TodayHeartbeats *Decimal `json:"todayHeartbeats"`
+	// Heartbeats Worker的心跳次数(worker_reward 接口的 heartbeat_count)
+	Heartbeats *Decimal `json:"heartbeats"`
+	// Checkin 今日签到积分,尚未签到时为0
+	Checkin *Decimal `json:"checkin"`
+	// Tier 已领取的等级奖励合计
+	Tier *Decimal `json:"tier"`
+}
 
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return 0, fmt.Errorf("failed to create request: %w", err)
+// Total 总积分即累计积分,未知时为nil
+// 心跳接口只返回次数,不计入总分;签到和等级积分单独列出,不重复计入
+func (e Earnings) Total() *Decimal {
+	if e.Base == nil {
+		return nil
 	}
+	total := *e.Base
+	return &total
+}
 
-	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("Content-Type", "application/json")
+// known 返回指向已知值的指针
+func known(d Decimal) *Decimal {
+	return &d
+}
 
-	// 发送请求
-	client := &http.Client{}
-	if proxy != "" {
-		if transport, err := o.getProxyClient(proxy); err == nil {
-			client.Transport = transport
-		}
-	}
+// sampleEarnings 查询累计积分和心跳次数,失败的部分保持未知并返回所有错误
+func (o *OpenLedger) sampleEarnings(account, proxy string) (Earnings, error) {
+	var earnings Earnings
+	var errs []error
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return 0, fmt.Errorf("request failed: %w", err)
+	if base, err := o.getUserReward(account, proxy); err != nil {
+		errs = append(errs, fmt.Errorf("get reward failed: %w", err))
+	} else {
+		earnings.Base = known(base)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode == 401 {
-		newToken, err := o.renewToken(account, proxy)
-		if err != nil {
-			return 0, fmt.Errorf("token renewal failed: %w", err)
-		}
-		return o.getUserReward(account, newToken, proxy)
+	if today, err := o.getRealtimeReward(account, proxy); err != nil {
+		errs = append(errs, fmt.Errorf("get realtime reward failed: %w", err))
+	} else {
+		earnings.TodayHeartbeats = today
 	}
 
-	var result UserRewardResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return 0, fmt.Errorf("failed to decode response: %w", err)
+	if heartbeats, err := o.getWorkerReward(account, proxy); err != nil {
+		errs = append(errs, fmt.Errorf("get worker reward failed: %w", err))
+	} else {
+		earnings.Heartbeats = known(heartbeats)
 	}
 
-	reward, err := strconv.ParseFloat(result.Data.TotalPoint, 64)
-	if err != nil {
-		return 0, fmt.Errorf("failed to parse reward: %w", err)
-	}
-
-	return reward, nil
+	return earnings, errors.Join(errs...)
 }
 
-// getWorkerReward 获取工作者奖励
-func (o *OpenLedger) getWorkerReward(account, token, proxy string) (float64, error) {
-	url := "https://rewardstn.openledger.xyz/api/v1/worker_reward"
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return 0, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	if proxy != "" {
-		if transport, err := o.getProxyClient(proxy); err == nil {
-			client.Transport = transport
+// claimedTierPoints 已领取等级奖励的合计
+func claimedTierPoints(tiers []openledger.TierDetail) Decimal {
+	var sum Decimal
+	for _, tier := range tiers {
+		if tier.ClaimStatus {
+			sum = sum.Add(tier.Value)
 		}
 	}
+	return sum
+}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return 0, fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == 401 {
-		newToken, err := o.renewToken(account, proxy)
-		if err != nil {
-			return 0, fmt.Errorf("token renewal failed: %w", err)
-		}
-		return o.getWorkerReward(account, newToken, proxy)
+// formatPoints 积分保留两位小数,未知时显示 unknown
+func formatPoints(d *Decimal) string {
+	if d == nil {
+		return "unknown"
 	}
+	return d.StringFixed(2)
+}
 
-	var result WorkerRewardResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return 0, fmt.Errorf("failed to decode response: %w", err)
+// formatCount 整数计数,未知时显示 unknown
+func formatCount(d *Decimal) string {
+	if d == nil {
+		return "unknown"
 	}
+	return d.StringFixed(0)
+}
 
-	if len(result.Data) == 0 {
-		return 0, nil
+// formatDelta 带符号的积分变化,未知时显示 unknown
+func formatDelta(d *Decimal) string {
+	if d == nil {
+		return "unknown"
 	}
-
-	// 处理空字符串情况
-	if result.Data[0].HeartbeatCount == "" {
-		result.Data[0].HeartbeatCount = "0"
+	if d.Sign() >= 0 {
+		return "+" + d.StringFixed(2)
 	}
+	return d.StringFixed(2)
+}
 
-	heartbeat, err := strconv.ParseFloat(result.Data[0].HeartbeatCount, 64)
+// getUserReward 获取用户奖励
+func (o *OpenLedger) getUserReward(account, proxy string) (Decimal, error) {
+	var result *openledger.UserRewardResponse
+	err := o.callAPI(account, proxy, func(client *openledger.Client, token string) (err error) {
+		result, err = client.Reward(context.Background(), token)
+		return err
+	})
 	if err != nil {
-		return 0, fmt.Errorf("failed to parse heartbeat count: %w", err)
+		return Decimal{}, err
 	}
 
-	return heartbeat, nil
+	return result.Data.TotalPoint, nil
 }
 
-// ProcessUserEarning 处理用户收益查询
-func (o *OpenLedger) ProcessUserEarning(account, token, proxy string, errChan chan<- error) {
-	for o.running {
-		reward := float64(0) // 基础奖励
-		heartbeat_today := float64(0)
-
-		// 获取基础奖励（总分）
-		if userReward, err := o.getUserReward(account, token, proxy); err == nil {
-			reward = userReward
-		}
-
-		// 获取今日实时奖励
-		if realtimeReward, err := o.getRealtimeReward(account, token, proxy); err == nil {
-			heartbeat_today = realtimeReward
-		}
-
-		totalPoint := reward + heartbeat_today // 总分 = 基础奖励 + 今日奖励
-
-		o.log(fmt.Sprintf("%s Account: %s - Earning: Total %.2f PTS - Today %.2f PTS",
-			color.CyanString("["),
-			color.WhiteString(o.hideAccount(account)),
-			totalPoint,
-			heartbeat_today))
-
-		// 每10分钟查询一次
-		time.Sleep(10 * time.Minute)
-	}
-}
-
-// getRealtimeReward 获取实时奖励
-func (o *OpenLedger) getRealtimeReward(account, token, proxy string) (float64, error) {
-	url := "https://rewardstn.openledger.xyz/api/v1/reward_realtime"
-
-	req, err := http.NewRequest("GET", url, nil)
+// getWorkerReward 获取工作者奖励
+func (o *OpenLedger) getWorkerReward(account, proxy string) (Decimal, error) {
+	var result *openledger.WorkerRewardResponse
+	err := o.callAPI(account, proxy, func(client *openledger.Client, token string) (err error) {
+		result, err = client.WorkerReward(context.Background(), token)
+		return err
+	})
 	if err != nil {
-		return 0, fmt.Errorf("failed to create request: %w", err)
+		return Decimal{}, err
 	}
 
-	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	if proxy != "" {
-		if transport, err := o.getProxyClient(proxy); err == nil {
-			client.Transport = transport
-		}
+	if len(result.Data) == 0 {
+		return Decimal{}, nil
 	}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return 0, fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
+	return result.Data[0].HeartbeatCount, nil
+}
 
-	if resp.StatusCode == 401 {
-		newToken, err := o.renewToken(account, proxy)
+// ProcessUserEarning 处理用户收益查询
+func (o *OpenLedger) ProcessUserEarning(account, proxy string, errChan chan<- error) {
+	rt := o.runtime(account)
+	for o.active(rt) {
+		o.waitIfPaused(rt)
+
+		sample, err := o.sampleEarnings(account, proxy)
 		if err != nil {
-			return 0, fmt.Errorf("token renewal failed: %w", err)
+			errChan <- err
 		}
-		return o.getRealtimeReward(account, newToken, proxy)
-	}
+		earnings, delta := rt.setEarnings(sample)
+		o.emit(account, &EarningsSampled{Earnings: earnings, Delta: delta})
 
-	var result RealtimeRewardResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		if strings.Contains(err.Error(), "invalid character") {
-			return 0, nil
-		}
-		return 0, fmt.Errorf("failed to decode response: %w", err)
+		// 按配置的间隔查询(默认10分钟)
+		rt.sleep(time.Duration(o.settings().EarningInterval), nil)
 	}
+}
 
-	if len(result.Data) == 0 {
-		return 0, nil
+// getRealtimeReward 获取今日心跳次数,接口返回非JSON内容时为未知(nil)
+func (o *OpenLedger) getRealtimeReward(account, proxy string) (*Decimal, error) {
+	var result *openledger.RealtimeRewardResponse
+	err := o.callAPI(account, proxy, func(client *openledger.Client, token string) (err error) {
+		result, err = client.RealtimeReward(context.Background(), token)
+		return err
+	})
+	// 没有今日数据时接口可能返回非JSON内容,无法确定次数
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return nil, nil
 	}
-
-	if result.Data[0].TotalHeartbeats == "" {
-		return 0, nil
+	if err != nil {
+		return nil, err
 	}
 
-	reward, err := strconv.ParseFloat(result.Data[0].TotalHeartbeats, 64)
-	if err != nil {
-		return 0, fmt.Errorf("failed to parse reward: %w", err)
+	if len(result.Data) == 0 {
+		return known(Decimal{}), nil
 	}
 
-	return reward, nil
+	return known(result.Data[0].TotalHeartbeats), nil
 }
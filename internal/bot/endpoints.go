@@ -0,0 +1,80 @@
+package bot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"openledger/pkg/openledger"
+)
+
+// Endpoints 一组OpenLedger服务地址
+type Endpoints = openledger.Endpoints
+
+// DefaultProfile 默认使用的环境
+const DefaultProfile = openledger.DefaultProfile
+
+// ResolveEndpoints 根据配置选择环境,配置文件中的同名环境优先于内置环境
+func (c Config) ResolveEndpoints() (Endpoints, error) {
+	name := c.Profile
+	if name == "" {
+		name = DefaultProfile
+	}
+
+	endpoints, ok := c.Profiles[name]
+	if !ok {
+		endpoints, ok = openledger.Profile(name)
+	}
+	if !ok {
+		return Endpoints{}, fmt.Errorf("unknown profile %q (available: %s)", name, strings.Join(c.profileNames(), ", "))
+	}
+
+	if endpoints.Auth == "" || endpoints.Rewards == "" || endpoints.Orchestrator == "" {
+		return Endpoints{}, fmt.Errorf("profile %q must define auth, rewards and orchestrator endpoints", name)
+	}
+
+	return endpoints.Normalize(), nil
+}
+
+// profileNames 返回所有可用环境名称
+func (c Config) profileNames() []string {
+	names := openledger.ProfileNames()
+	for name := range c.Profiles {
+		if _, ok := openledger.Profile(name); !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// profileName 返回当前环境名称
+func (o *OpenLedger) profileName() string {
+	if name := o.settings().Profile; name != "" {
+		return name
+	}
+	return DefaultProfile
+}
+
+// endpoints 获取当前环境的服务地址
+func (o *OpenLedger) endpoints() Endpoints {
+	endpoints, err := o.settings().ResolveEndpoints()
+	if err != nil {
+		// 配置在加载时已校验,这里只作兜底
+		endpoints, _ = openledger.Profile(DefaultProfile)
+	}
+	return endpoints
+}
+
+// sameProfiles 比较两组自定义环境是否相同
+func sameProfiles(a, b map[string]Endpoints) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, endpoints := range a {
+		if other, ok := b[name]; !ok || other != endpoints {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,375 @@
+package bot
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Event 机器人生命周期事件,订阅者通过类型断言区分具体事件
+type Event interface {
+	base() *EventBase
+}
+
+// EventBase 所有事件共有的字段
+type EventBase struct {
+	Account string    `json:"account"`
+	Time    time.Time `json:"time"`
+}
+
+func (b *EventBase) base() *EventBase {
+	return b
+}
+
+// TokenGenerated 生成或更新访问令牌成功
+type TokenGenerated struct {
+	EventBase
+	Renewed   bool       `json:"renewed"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// AuthFailed 生成或更新访问令牌失败
+type AuthFailed struct {
+	EventBase
+	Renewal bool  `json:"renewal"`
+	Err     error `json:"-"`
+}
+
+// WSConnected WebSocket连接已建立
+type WSConnected struct {
+	EventBase
+	Proxy    string `json:"proxy"`
+	WorkerID string `json:"workerId"`
+}
+
+// WSRegistered WebSocket注册成功
+type WSRegistered struct {
+	EventBase
+}
+
+// WSClosed 已建立的WebSocket连接断开,Code 和 Reason 为服务端关闭帧的内容,没有关闭帧时为空
+type WSClosed struct {
+	EventBase
+	Proxy    string `json:"proxy"`
+	WorkerID string `json:"workerId"`
+	Code     int    `json:"code,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// HeartbeatAcked 服务端确认了一次心跳,Latency 为与对应心跳之间的延迟,无法配对时为0
+type HeartbeatAcked struct {
+	EventBase
+	Latency time.Duration `json:"latency"`
+}
+
+// HeartbeatsMissed 连续 Streak 次心跳没有被确认,会话视为降级
+type HeartbeatsMissed struct {
+	EventBase
+	Streak int `json:"streak"`
+}
+
+// JobAssigned 收到并确认了一个任务
+type JobAssigned struct {
+	EventBase
+	JobID string `json:"jobId"`
+}
+
+// CheckinClaimed 领取签到奖励成功
+type CheckinClaimed struct {
+	EventBase
+	Points Decimal `json:"points"`
+}
+
+// TierClaimed 领取等级奖励成功
+type TierClaimed struct {
+	EventBase
+	TierID int     `json:"tierId"`
+	Tier   string  `json:"tier"`
+	Points Decimal `json:"points"`
+}
+
+// EarningsSampled 完成一次积分查询,Earnings 为合并签到和等级积分后的明细
+type EarningsSampled struct {
+	EventBase
+	Earnings Earnings `json:"earnings"`
+	Delta    *Decimal `json:"delta"`
+}
+
+// TaskPanicked 账号的任务发生panic,已恢复
+type TaskPanicked struct {
+	EventBase
+	Task  string `json:"task"`
+	Value string `json:"value"`
+	Stack string `json:"stack"`
+}
+
+// TaskRestarting 账号的任务异常退出,等待 Delay 后重启
+type TaskRestarting struct {
+	EventBase
+	Task    string        `json:"task"`
+	Attempt int           `json:"attempt"`
+	Delay   time.Duration `json:"delay"`
+	Err     error         `json:"-"`
+}
+
+// AccountSuspended 账号连续失败次数过多,已挂起,恢复后重新启动
+type AccountSuspended struct {
+	EventBase
+	Failures int   `json:"failures"`
+	Err      error `json:"-"`
+}
+
+// StateChanged 账号状态发生变化
+type StateChanged struct {
+	EventBase
+	From   AccountState `json:"from"`
+	To     AccountState `json:"to"`
+	Reason string       `json:"reason"`
+}
+
+// HeartbeatsReconciled 一次心跳对账完成
+type HeartbeatsReconciled struct {
+	EventBase
+	ReconcileResult
+}
+
+// eventBus 进程内的事件分发
+// 订阅者按订阅顺序在发布事件的goroutine中同步调用,不能阻塞,耗时的处理应自行排队
+type eventBus struct {
+	mu       sync.Mutex
+	next     int
+	handlers []eventHandler
+}
+
+type eventHandler struct {
+	id     int
+	handle func(Event)
+}
+
+func newEventBus() *eventBus {
+	return &eventBus{}
+}
+
+// subscribe 添加订阅者,返回取消订阅的函数
+func (b *eventBus) subscribe(handle func(Event)) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.next++
+	id := b.next
+	b.handlers = append(b.handlers, eventHandler{id: id, handle: handle})
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for i, h := range b.handlers {
+			if h.id == id {
+				b.handlers = append(b.handlers[:i:i], b.handlers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// publish 依次通知所有订阅者
+func (b *eventBus) publish(e Event) {
+	b.mu.Lock()
+	handlers := b.handlers
+	b.mu.Unlock()
+
+	for _, h := range handlers {
+		h.handle(e)
+	}
+}
+
+// Subscribe 订阅生命周期事件,返回取消订阅的函数
+func (o *OpenLedger) Subscribe(handle func(Event)) func() {
+	return o.events.subscribe(handle)
+}
+
+// emit 填充账号和时间后发布事件
+func (o *OpenLedger) emit(account string, e Event) {
+	b := e.base()
+	b.Account = account
+	if b.Time.IsZero() {
+		b.Time = time.Now()
+	}
+	o.events.publish(e)
+}
+
+// trackEvent 根据WebSocket事件更新账号的运行时状态,最先订阅,之后的订阅者可以读到更新后的快照
+func (o *OpenLedger) trackEvent(e Event) {
+	switch e := e.(type) {
+	case *WSConnected:
+		o.runtime(e.Account).setWSStatus(wsStatusConnected)
+	case *WSRegistered:
+		o.runtime(e.Account).setWSStatus(wsStatusRegistered)
+	case *WSClosed:
+		o.runtime(e.Account).setWSStatus(wsStatusClosed)
+	}
+}
+
+// logEvent 将事件输出为日志
+func (o *OpenLedger) logEvent(e Event) {
+	switch e := e.(type) {
+	case *TokenGenerated:
+		if e.Renewed {
+			o.log(fmt.Sprintf("%s Account %s - Access Token Has Been Renewed",
+				color.GreenString("✓"),
+				color.WhiteString(o.displayName(e.Account))))
+		} else {
+			o.log(fmt.Sprintf("%s Account %s - Token generated successfully",
+				color.GreenString("✓"),
+				color.WhiteString(o.displayName(e.Account))))
+		}
+
+	case *AuthFailed:
+		if e.Renewal {
+			o.log(fmt.Sprintf("%s Account %s - Failed to Renew Access Token",
+				color.RedString("✗"),
+				color.WhiteString(o.displayName(e.Account))))
+		} else {
+			o.log(fmt.Sprintf("%s Account %s - Failed to generate initial token: %v",
+				color.RedString("✗"),
+				color.WhiteString(o.displayName(e.Account)),
+				e.Err))
+		}
+
+	case *WSConnected:
+		o.log(fmt.Sprintf("%s Account: %s - Proxy: %s - Worker ID: %s - Status: %s",
+			color.CyanString("["),
+			color.WhiteString(o.displayName(e.Account)),
+			color.WhiteString(e.Proxy),
+			color.WhiteString(o.maskWorkerID(e.WorkerID)),
+			color.GreenString("Webscoket Is Connected")))
+
+	case *WSRegistered:
+		o.log(fmt.Sprintf("%s Account %s - WebSocket registered successfully",
+			color.GreenString("✓"),
+			color.WhiteString(o.displayName(e.Account))))
+
+	case *WSClosed:
+		o.log(fmt.Sprintf("%s Account: %s - Proxy: %s - Worker ID: %s - Status: %s",
+			color.CyanString("["),
+			color.WhiteString(o.displayName(e.Account)),
+			color.WhiteString(e.Proxy),
+			color.WhiteString(o.maskWorkerID(e.WorkerID)),
+			color.YellowString("Webscoket Connection Closed")))
+
+	case *HeartbeatAcked:
+		if e.Latency > 0 {
+			o.log(fmt.Sprintf("%s Account %s - Heartbeat acknowledged in %s",
+				color.GreenString("✓"),
+				color.WhiteString(o.displayName(e.Account)),
+				e.Latency.Round(time.Microsecond)))
+		} else {
+			o.log(fmt.Sprintf("%s Account %s - Heartbeat acknowledged",
+				color.GreenString("✓"),
+				color.WhiteString(o.displayName(e.Account))))
+		}
+
+	case *HeartbeatsMissed:
+		o.log(fmt.Sprintf("%s Account %s - %d heartbeats in a row were not acknowledged",
+			color.RedString("✗"),
+			color.WhiteString(o.displayName(e.Account)),
+			e.Streak))
+
+	case *JobAssigned:
+		o.log(fmt.Sprintf("%s Account %s - Job assigned",
+			color.GreenString("✓"),
+			color.WhiteString(o.displayName(e.Account))))
+
+	case *CheckinClaimed:
+		o.log(fmt.Sprintf("%s Account: %s - Check-In: Is Claimed - Reward: %s PTS",
+			color.CyanString("["),
+			color.WhiteString(o.displayName(e.Account)),
+			e.Points.StringFixed(2)))
+
+	case *TierClaimed:
+		o.log(fmt.Sprintf("%s Account: %s - Tier: %s - Status: Is Claimed - Reward: %s PTS",
+			color.CyanString("["),
+			color.WhiteString(o.displayName(e.Account)),
+			e.Tier,
+			e.Points.StringFixed(2)))
+
+	case *EarningsSampled:
+		o.log(fmt.Sprintf("%s Account: %s - Earning: Total %s PTS (%s) - Heartbeats today %s (worker %s) - Check-In %s - Tier %s",
+			color.CyanString("["),
+			color.WhiteString(o.displayName(e.Account)),
+			formatPoints(e.Earnings.Total()),
+			formatDelta(e.Delta),
+			formatCount(e.Earnings.TodayHeartbeats),
+			formatCount(e.Earnings.Heartbeats),
+			formatPoints(e.Earnings.Checkin),
+			formatPoints(e.Earnings.Tier)))
+
+	case *TaskPanicked:
+		o.log(fmt.Sprintf("%s Account %s - %s task panicked: %s\n%s",
+			color.RedString("✗"),
+			color.WhiteString(o.displayName(e.Account)),
+			e.Task,
+			e.Value,
+			e.Stack))
+
+	case *TaskRestarting:
+		o.log(fmt.Sprintf("%s Account %s - Restarting %s task in %s (failure %d/%d): %v",
+			color.YellowString("!"),
+			color.WhiteString(o.displayName(e.Account)),
+			e.Task,
+			e.Delay,
+			e.Attempt,
+			suspendAfter,
+			e.Err))
+
+	case *AccountSuspended:
+		o.log(fmt.Sprintf("%s Account %s - Suspended after %d failures, resume it to restart: %v",
+			color.RedString("✗"),
+			color.WhiteString(o.displayName(e.Account)),
+			e.Failures,
+			e.Err))
+
+	case *HeartbeatsReconciled:
+		switch {
+		case e.Baseline:
+			o.log(fmt.Sprintf("%s Account %s - Heartbeat reconciliation started: heartbeat_count %s",
+				color.CyanString("["),
+				color.WhiteString(o.displayName(e.Account)),
+				e.HeartbeatCount.StringFixed(0)))
+		case e.Dropped:
+			o.log(fmt.Sprintf("%s Account %s - Heartbeats not credited: acked %d, credited %s since last check (today %d of %d credited)",
+				color.RedString("✗"),
+				color.WhiteString(o.displayName(e.Account)),
+				e.Acked,
+				e.credits(),
+				e.Today.Credited,
+				e.Today.Acked))
+		default:
+			o.log(fmt.Sprintf("%s Account %s - Heartbeats reconciled: acked %d, credited %s since last check (today %d of %d credited)",
+				color.CyanString("["),
+				color.WhiteString(o.displayName(e.Account)),
+				e.Acked,
+				e.credits(),
+				e.Today.Credited,
+				e.Today.Acked))
+		}
+
+	case *StateChanged:
+		state := string(e.To)
+		switch e.To {
+		case StateHealthy, StateRegistered:
+			state = color.GreenString(state)
+		case StateDegraded, StateSuspended:
+			state = color.RedString(state)
+		default:
+			state = color.YellowString(state)
+		}
+		o.log(fmt.Sprintf("%s Account %s - State: %s -> %s (%s)",
+			color.CyanString("["),
+			color.WhiteString(o.displayName(e.Account)),
+			e.From,
+			state,
+			e.Reason))
+	}
+}
@@ -0,0 +1,118 @@
+package bot
+
+import (
+	"time"
+)
+
+// missedAckLimit 连续多少次心跳未被确认时视为会话降级
+const missedAckLimit = 3
+
+// maxPendingHeartbeats 最多保留的未确认心跳,更早的视为丢失,不再参与配对
+const maxPendingHeartbeats = 8
+
+// heartbeatStats 心跳发送与确认的统计,由 accountRuntime.mu 保护
+// 服务端的确认不带心跳编号,按发送顺序与当前连接上最早未确认的心跳配对;
+// 确认丢失后队列会错位,因此配对前丢弃早于一个心跳间隔的心跳,延迟只由最近一次心跳得出
+type heartbeatStats struct {
+	// pending 当前连接上已发送、尚未确认的心跳发送时间
+	pending []time.Time
+	// sent/acked 最近一小时发送和确认的心跳时间
+	sent  []time.Time
+	acked []time.Time
+	// totalAcked 启动以来确认的心跳总数,用于对账
+	totalAcked int64
+	// missedStreak 连续未被确认的心跳数,下一次心跳发出时之前的心跳仍未确认即算作未确认
+	missedStreak int
+	lastLatency  time.Duration
+	avgLatency   time.Duration
+}
+
+// prune 只保留最近一小时的记录
+func (h *heartbeatStats) prune(now time.Time) {
+	cutoff := now.Add(-time.Hour)
+	h.sent = pruneBefore(h.sent, cutoff)
+	h.acked = pruneBefore(h.acked, cutoff)
+}
+
+func pruneBefore(times []time.Time, cutoff time.Time) []time.Time {
+	i := 0
+	for i < len(times) && times[i].Before(cutoff) {
+		i++
+	}
+	return times[i:]
+}
+
+// ackRatio 最近一小时的确认比例,没有发送过心跳时为nil
+func (h *heartbeatStats) ackRatio() *float64 {
+	if len(h.sent) == 0 {
+		return nil
+	}
+	ratio := float64(len(h.acked)) / float64(len(h.sent))
+	if ratio > 1 {
+		ratio = 1
+	}
+	return &ratio
+}
+
+// milliseconds 以毫秒表示的时长,为0时为nil
+func milliseconds(d time.Duration) *float64 {
+	if d == 0 {
+		return nil
+	}
+	ms := float64(d) / float64(time.Millisecond)
+	return &ms
+}
+
+// resetHeartbeats 新连接建立时清空未确认的心跳
+func (rt *accountRuntime) resetHeartbeats() {
+	rt.mu.Lock()
+	rt.heartbeats.pending = nil
+	rt.mu.Unlock()
+}
+
+// recordHeartbeatSent 记录一次心跳发送,返回连续未确认的心跳数
+func (rt *accountRuntime) recordHeartbeatSent(now time.Time) int {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	h := &rt.heartbeats
+	if len(h.pending) > 0 {
+		h.missedStreak++
+	}
+	h.pending = append(h.pending, now)
+	if len(h.pending) > maxPendingHeartbeats {
+		h.pending = h.pending[len(h.pending)-maxPendingHeartbeats:]
+	}
+	h.sent = append(h.sent, now)
+	h.prune(now)
+	return h.missedStreak
+}
+
+// recordHeartbeatAck 记录一次心跳确认,返回与对应心跳之间的延迟,没有可配对的心跳时返回false
+// 发送时间早于 expireAfter 的未确认心跳视为确认已丢失,不参与配对
+func (rt *accountRuntime) recordHeartbeatAck(now time.Time, expireAfter time.Duration) (time.Duration, bool) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	h := &rt.heartbeats
+	h.acked = append(h.acked, now)
+	h.prune(now)
+	h.missedStreak = 0
+	h.totalAcked++
+
+	h.pending = pruneBefore(h.pending, now.Add(-expireAfter))
+	if len(h.pending) == 0 {
+		return 0, false
+	}
+	latency := now.Sub(h.pending[0])
+	h.pending = h.pending[1:]
+
+	h.lastLatency = latency
+	if h.avgLatency == 0 {
+		h.avgLatency = latency
+	} else {
+		// 指数移动平均,最近的样本权重为1/5
+		h.avgLatency += (latency - h.avgLatency) / 5
+	}
+	return latency, true
+}
@@ -0,0 +1,12 @@
+package bot
+
+import "golang.org/x/crypto/sha3"
+
+// keccak256 计算以太坊使用的Keccak-256哈希(原始Keccak填充,不同于SHA3-256)
+func keccak256(data []byte) [32]byte {
+	var sum [32]byte
+	h := sha3.NewLegacyKeccak256()
+	h.Write(data)
+	h.Sum(sum[:0])
+	return sum
+}
@@ -0,0 +1,77 @@
+package bot
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestKeccak256(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", "c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470"},
+		{"abc", "4e03657aea45a94fc7d47ba826c8d667c0d1e6e33a64a036ec44f58fa12d6c45"},
+	}
+	for _, tt := range tests {
+		sum := keccak256([]byte(tt.in))
+		if got := hex.EncodeToString(sum[:]); got != tt.want {
+			t.Errorf("keccak256(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+// eip55Vectors EIP-55 规范中的示例地址
+var eip55Vectors = []string{
+	"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+	"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+	"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+	"0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb",
+}
+
+func TestChecksumAddress(t *testing.T) {
+	for _, want := range eip55Vectors {
+		if got := checksumAddress(strings.ToLower(want[2:])); got != want {
+			t.Errorf("checksumAddress(%s) = %s, want %s", strings.ToLower(want), got, want)
+		}
+	}
+}
+
+func TestValidateAddress(t *testing.T) {
+	for _, address := range eip55Vectors {
+		if err := validateAddress(address); err != nil {
+			t.Errorf("validateAddress(%s): %v", address, err)
+		}
+		if err := validateAddress(strings.ToLower(address)); err != nil {
+			t.Errorf("validateAddress(%s): %v", strings.ToLower(address), err)
+		}
+	}
+
+	invalid := []string{
+		"5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAe",
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeg",
+		// 大小写混合但校验和错误
+		"0x5AAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+	}
+	for _, address := range invalid {
+		if err := validateAddress(address); err == nil {
+			t.Errorf("validateAddress(%s) succeeded, want error", address)
+		}
+	}
+}
+
+func TestAccountKeyIgnoresCase(t *testing.T) {
+	o := &OpenLedger{runtimes: make(map[string]*accountRuntime)}
+	address := eip55Vectors[0]
+	o.setAccounts([]Account{newAccount(strings.ToLower(address))})
+	rt := o.registerRuntime(strings.ToLower(address))
+
+	if o.runtime(address) != rt {
+		t.Error("checksummed address did not find the runtime registered in lower case")
+	}
+	if got := o.accountConfig(address).Address; got != strings.ToLower(address) {
+		t.Errorf("accountConfig(%s).Address = %s", address, got)
+	}
+}
@@ -1,9 +1,12 @@
 package bot
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/fatih/color"
@@ -12,17 +15,26 @@ import (
 // Logger 日志记录器
 type Logger struct {
 	logFile *os.File
+	mu      sync.Mutex
+	out     io.Writer
+	output  func(line string)
+	json    *json.Encoder
 }
 
-// NewLogger 创建新的日志记录器
+// NewLogger 创建新的日志记录器,同时写入 logs 目录下的日志文件和标准输出
 func NewLogger() (*Logger, error) {
+	return newFileLogger("logs", os.Stdout)
+}
+
+// newFileLogger 创建同时写入dir目录下当天的日志文件和out的日志记录器
+func newFileLogger(dir string, out io.Writer) (*Logger, error) {
 	// 创建logs目录
-	if err := os.MkdirAll("logs", 0755); err != nil {
+	if err := os.MkdirAll(dir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create logs directory: %w", err)
 	}
 
 	// 创建日志文件
-	logFileName := fmt.Sprintf("logs/openledger_%s.log", time.Now().Format("2006-01-02"))
+	logFileName := filepath.Join(dir, fmt.Sprintf("openledger_%s.log", time.Now().Format("2006-01-02")))
 	logFile, err := os.OpenFile(logFileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create log file: %w", err)
@@ -30,9 +42,15 @@ func NewLogger() (*Logger, error) {
 
 	return &Logger{
 		logFile: logFile,
+		out:     out,
 	}, nil
 }
 
+// NewConsoleLogger 创建只输出到w的日志记录器,不创建日志文件
+func NewConsoleLogger(w io.Writer) *Logger {
+	return &Logger{out: w}
+}
+
 // Close 关闭日志文件
 func (l *Logger) Close() error {
 	if l.logFile != nil {
@@ -41,23 +59,55 @@ func (l *Logger) Close() error {
 	return nil
 }
 
-// Log 记录日志
+// SetOutput 设置控制台输出的替代函数,为nil时恢复直接打印
+func (l *Logger) SetOutput(output func(line string)) {
+	l.mu.Lock()
+	l.output = output
+	l.mu.Unlock()
+}
+
+// SetJSONOutput 将控制台输出改为每行一个JSON对象,便于脚本处理
+func (l *Logger) SetJSONOutput(w io.Writer) {
+	l.mu.Lock()
+	l.json = json.NewEncoder(w)
+	l.mu.Unlock()
+}
+
+// Log 记录日志,写入前遮盖代理账号密码和令牌
 func (l *Logger) Log(message string) {
 	// 获取当前时间
 	now := time.Now().Format("2006-01-02 15:04:05 MST")
+	message = Redact(message)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
 
 	// 写入日志文件
 	if l.logFile != nil {
 		fmt.Fprintf(l.logFile, "[%s] %s\n", now, message)
 	}
 
+	if l.json != nil {
+		l.json.Encode(struct {
+			Time    string `json:"time"`
+			Message string `json:"message"`
+		}{now, ansiPattern.ReplaceAllString(message, "")})
+		return
+	}
+
+	// 交给面板等替代输出
+	if l.output != nil {
+		l.output(fmt.Sprintf("[ %s ] | %s", now, message))
+		return
+	}
+
 	// 打印到控制台
 	cyan := color.New(color.FgCyan, color.Bold)
 	white := color.New(color.FgWhite, color.Bold)
 	
-	cyan.Printf("[ %s ]", now)
-	white.Print(" | ")
-	fmt.Println(message)
+	cyan.Fprintf(l.out, "[ %s ]", now)
+	white.Fprint(l.out, " | ")
+	fmt.Fprintln(l.out, message)
 }
 
 // CleanOldLogs 清理旧日志文件
@@ -0,0 +1,133 @@
+package bot
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/fatih/color"
+
+	"openledger/pkg/openledger"
+)
+
+// 账号的显示方式
+const (
+	// MaskPartial 显示地址的前后6位
+	MaskPartial = "partial"
+	// MaskAlias 只显示别名,没有别名时显示账号在账号文件中的序号
+	MaskAlias = "alias"
+	// MaskPseudonym 显示按密钥计算的HMAC假名,同一密钥下保持不变
+	MaskPseudonym = "pseudonym"
+)
+
+// pseudonymKeyFile 未配置假名密钥时,自动生成的密钥保存位置
+const pseudonymKeyFile = "pseudonym_key.txt"
+
+// validateAccountMask 校验账号显示方式,空值等同于 partial
+func validateAccountMask(mode string) error {
+	switch mode {
+	case "", MaskPartial, MaskAlias, MaskPseudonym:
+		return nil
+	}
+	return fmt.Errorf("unknown accountMask %q (expected %s, %s or %s)", mode, MaskPartial, MaskAlias, MaskPseudonym)
+}
+
+// maskAccount 按配置的显示方式遮盖钱包地址,不考虑别名
+func (o *OpenLedger) maskAccount(address string) string {
+	switch o.settings().AccountMask {
+	case MaskAlias:
+		if index := o.accountConfig(address).index; index > 0 {
+			return fmt.Sprintf("account-%d", index)
+		}
+		return "account-?"
+	case MaskPseudonym:
+		return "acct-" + o.pseudonym(strings.ToLower(address))
+	default:
+		return o.hideAccount(address)
+	}
+}
+
+// addressPattern 文本中的钱包地址
+var addressPattern = regexp.MustCompile(`(?i)0x[0-9a-f]{40}`)
+
+// maskAddresses 将文本中的钱包地址(不区分大小写)和已登记账号的Worker ID替换为显示名称
+func (o *OpenLedger) maskAddresses(s string) string {
+	// Worker ID 是 "0x..." 的base64编码,都以 "MHg" 开头
+	if !strings.Contains(s, "0x") && !strings.Contains(s, "0X") && !strings.Contains(s, "MHg") {
+		return s
+	}
+
+	o.accountMutex.Lock()
+	addresses := make(map[string]string, len(o.accounts))
+	for key, account := range o.accounts {
+		addresses[key] = account.Address
+	}
+	o.accountMutex.Unlock()
+
+	s = addressPattern.ReplaceAllStringFunc(s, func(match string) string {
+		if address, ok := addresses[strings.ToLower(match)]; ok {
+			return o.displayName(address)
+		}
+		return o.maskAccount(match)
+	})
+	for _, address := range addresses {
+		if worker := openledger.WorkerID(address); strings.Contains(s, worker) {
+			s = strings.ReplaceAll(s, worker, o.maskWorkerID(worker))
+		}
+	}
+	return s
+}
+
+// maskWorkerID 遮盖Worker ID,Worker ID由地址编码而来,与地址使用相同的显示方式
+func (o *OpenLedger) maskWorkerID(workerID string) string {
+	switch o.settings().AccountMask {
+	case MaskAlias:
+		return "<hidden>"
+	case MaskPseudonym:
+		return "worker-" + o.pseudonym(workerID)
+	default:
+		return o.hideAccount(workerID)
+	}
+}
+
+// pseudonym HMAC-SHA256 的前5个字节
+func (o *OpenLedger) pseudonym(value string) string {
+	mac := hmac.New(sha256.New, o.pseudonymKey())
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil)[:5])
+}
+
+// pseudonymKey 获取假名密钥: 配置中的密钥优先,其次为密钥文件,都没有时生成并写入文件
+func (o *OpenLedger) pseudonymKey() []byte {
+	if key := o.settings().PseudonymKey; key != "" {
+		return []byte(key)
+	}
+
+	o.pseudonymOnce.Do(func() {
+		data, err := os.ReadFile(pseudonymKeyFile)
+		if key := strings.TrimSpace(string(data)); err == nil && key != "" {
+			o.generatedKey = []byte(key)
+			return
+		}
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			o.log(color.YellowString("Failed to read %s: %v", pseudonymKeyFile, err))
+		}
+
+		buf := make([]byte, 32)
+		rand.Read(buf)
+		key := hex.EncodeToString(buf)
+		o.generatedKey = []byte(key)
+		if err := os.WriteFile(pseudonymKeyFile, []byte(key+"\n"), 0600); err != nil {
+			o.log(color.YellowString("Failed to write %s, pseudonyms change after restart: %v", pseudonymKeyFile, err))
+			return
+		}
+		o.log(color.YellowString("Pseudonym key written to %s", pseudonymKeyFile))
+	})
+	return o.generatedKey
+}
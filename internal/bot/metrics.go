@@ -0,0 +1,97 @@
+package bot
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// labelEscaper 转义Prometheus标签值
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// metric 一个指标及其每个账号的取值,取值为nil时不输出
+type metric struct {
+	name  string
+	help  string
+	value func(s accountSnapshot) *float64
+}
+
+func gauge(v float64) *float64 {
+	return &v
+}
+
+// seconds 毫秒值转换为秒
+func seconds(ms *float64) *float64 {
+	if ms == nil {
+		return nil
+	}
+	return gauge(*ms / 1000)
+}
+
+var accountMetrics = []metric{
+	{"openledger_heartbeats_sent", "Heartbeats sent in the last hour.", func(s accountSnapshot) *float64 {
+		return gauge(float64(s.HeartbeatsSent))
+	}},
+	{"openledger_heartbeats_acked", "Heartbeats acknowledged in the last hour.", func(s accountSnapshot) *float64 {
+		return gauge(float64(s.HeartbeatsAck))
+	}},
+	{"openledger_heartbeat_ack_ratio", "Share of heartbeats acknowledged in the last hour.", func(s accountSnapshot) *float64 {
+		return s.AckRatio
+	}},
+	{"openledger_heartbeat_ack_latency_seconds", "Latency of the last heartbeat acknowledgement.", func(s accountSnapshot) *float64 {
+		return seconds(s.AckLatencyMs)
+	}},
+	{"openledger_heartbeat_ack_latency_avg_seconds", "Moving average of the heartbeat acknowledgement latency.", func(s accountSnapshot) *float64 {
+		return seconds(s.AvgLatencyMs)
+	}},
+	{"openledger_heartbeat_missed_streak", "Heartbeats in a row that were not acknowledged.", func(s accountSnapshot) *float64 {
+		return gauge(float64(s.MissedAcks))
+	}},
+	{"openledger_heartbeats_acked_today", "Heartbeats acknowledged today (UTC) as of the last reconciliation.", func(s accountSnapshot) *float64 {
+		if s.Reconciliation == nil {
+			return nil
+		}
+		return gauge(float64(s.Reconciliation.Today.Acked))
+	}},
+	{"openledger_heartbeats_credited_today", "Heartbeats credited by the server today (UTC) as of the last reconciliation.", func(s accountSnapshot) *float64 {
+		if s.Reconciliation == nil {
+			return nil
+		}
+		return gauge(float64(s.Reconciliation.Today.Credited))
+	}},
+}
+
+// handleMetrics 以Prometheus文本格式输出每个账号的状态和心跳指标
+func (c *controlServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	c.o.writeMetrics(w)
+}
+
+// writeMetrics 输出指标,account 为显示名称,address 为按 accountMask 遮盖的地址
+// 别名可能重复或为空,由 address 区分不同账号的序列
+func (o *OpenLedger) writeMetrics(w io.Writer) {
+	snapshots := o.snapshots()
+	labels := make([]string, len(snapshots))
+	for i, s := range snapshots {
+		labels[i] = fmt.Sprintf("account=\"%s\",address=\"%s\"",
+			labelEscaper.Replace(o.displayName(s.Account)),
+			labelEscaper.Replace(o.maskAccount(s.Account)))
+	}
+
+	fmt.Fprintln(w, "# HELP openledger_account_state Current account state, 1 for the active state.")
+	fmt.Fprintln(w, "# TYPE openledger_account_state gauge")
+	for i, s := range snapshots {
+		fmt.Fprintf(w, "openledger_account_state{%s,state=\"%s\"} 1\n", labels[i], s.State)
+	}
+
+	for _, m := range accountMetrics {
+		fmt.Fprintf(w, "# HELP %s %s\n", m.name, m.help)
+		fmt.Fprintf(w, "# TYPE %s gauge\n", m.name)
+		for i, s := range snapshots {
+			if v := m.value(s); v != nil {
+				fmt.Fprintf(w, "%s{%s} %g\n", m.name, labels[i], *v)
+			}
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package bot
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMetricsSeriesAreUniqueForDuplicateAliases(t *testing.T) {
+	for _, mode := range []string{MaskPartial, MaskAlias, MaskPseudonym} {
+		t.Run(mode, func(t *testing.T) {
+			config := DefaultConfig()
+			config.AccountMask = mode
+			config.PseudonymKey = "metrics-test"
+			o := &OpenLedger{config: config, runtimes: make(map[string]*accountRuntime)}
+
+			var accounts []Account
+			for _, address := range eip55Vectors[:3] {
+				account := newAccount(address)
+				account.Alias = "worker"
+				accounts = append(accounts, account)
+				o.registerRuntime(address)
+			}
+			o.setAccounts(accounts)
+
+			var buf bytes.Buffer
+			o.writeMetrics(&buf)
+			series := make(map[string]bool)
+			for _, line := range strings.Split(buf.String(), "\n") {
+				if line == "" || strings.HasPrefix(line, "#") {
+					continue
+				}
+				name, _, _ := strings.Cut(line, " ")
+				if series[name] {
+					t.Errorf("duplicate series %s", name)
+				}
+				series[name] = true
+			}
+			if len(series) == 0 {
+				t.Fatal("no series written")
+			}
+		})
+	}
+}
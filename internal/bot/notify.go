@@ -0,0 +1,494 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// 可以发送通知的事件
+const (
+	EventAuthFailed     = "auth_failed"
+	EventWSDown         = "ws_down"
+	EventCheckinClaimed = "checkin_claimed"
+	EventTierClaimed    = "tier_claimed"
+	EventPointsStalled  = "points_stalled"
+	EventDailySummary   = "daily_summary"
+	// EventAccountSuspended 账号连续失败后被挂起
+	EventAccountSuspended = "account_suspended"
+	// EventHeartbeatsDropped 对账发现确认的心跳没有被计入
+	EventHeartbeatsDropped = "heartbeats_dropped"
+)
+
+// notifyDrainTimeout 关闭通知子系统时发送剩余通知的最长时间
+const notifyDrainTimeout = 10 * time.Second
+
+var notifyEvents = []string{EventAuthFailed, EventWSDown, EventCheckinClaimed, EventTierClaimed, EventPointsStalled, EventDailySummary, EventAccountSuspended, EventHeartbeatsDropped}
+
+// NotifyConfig 通知配置
+type NotifyConfig struct {
+	Webhooks []WebhookSinkConfig `json:"webhooks"`
+	SMTP     []SMTPSinkConfig    `json:"smtp"`
+	Commands []CommandSinkConfig `json:"commands"`
+
+	// WSDownAfter WebSocket断开超过该时长时发送 ws_down,默认10分钟
+	WSDownAfter Duration `json:"wsDownAfter"`
+	// StallWindow 总积分在该时长内没有增加时发送 points_stalled,默认2小时
+	StallWindow Duration `json:"stallWindow"`
+	// DailySummary 每天发送汇总的本地时间,如 "21:00",为空时不发送
+	DailySummary string `json:"dailySummary"`
+	// Dedup 同一账号的同一事件在该时长内只发送一次(等级奖励按等级区分),默认30分钟
+	Dedup Duration `json:"dedup"`
+	// MaxPerHour 每个通知渠道每小时最多发送的条数,默认20
+	MaxPerHour int `json:"maxPerHour"`
+}
+
+// Notification 一条通知
+type Notification struct {
+	Event   string    `json:"event"`
+	Account string    `json:"account,omitempty"`
+	Title   string    `json:"title"`
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+}
+
+// Sink 通知渠道
+type Sink interface {
+	Name() string
+	Send(n Notification) error
+}
+
+// sinkFilter 只接收配置的事件,为空时接收所有事件
+type sinkFilter []string
+
+func (f sinkFilter) accepts(event string) bool {
+	if len(f) == 0 {
+		return true
+	}
+	for _, e := range f {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// notifySink 带事件过滤和限流的通知渠道
+type notifySink struct {
+	Sink
+	events sinkFilter
+	// target 渠道的完整目标,重新加载时据此保留限流状态,不写入日志
+	target string
+	sent   []time.Time
+	warned bool
+}
+
+// allow 检查每小时发送上限
+func (s *notifySink) allow(now time.Time, limit int) bool {
+	cutoff := now.Add(-time.Hour)
+	i := 0
+	for i < len(s.sent) && s.sent[i].Before(cutoff) {
+		i++
+	}
+	s.sent = s.sent[i:]
+	if len(s.sent) >= limit {
+		return false
+	}
+	s.sent = append(s.sent, now)
+	s.warned = false
+	return true
+}
+
+// validate 校验通知配置
+func (c NotifyConfig) validate() error {
+	check := func(kind string, i int, events []string) error {
+		for _, event := range events {
+			known := false
+			for _, e := range notifyEvents {
+				known = known || e == event
+			}
+			if !known {
+				return fmt.Errorf("notify.%s[%d]: unknown event %q (available: %s)", kind, i, event, strings.Join(notifyEvents, ", "))
+			}
+		}
+		return nil
+	}
+
+	for i, sink := range c.Webhooks {
+		if sink.URL == "" {
+			return fmt.Errorf("notify.webhooks[%d]: url is required", i)
+		}
+		if err := check("webhooks", i, sink.Events); err != nil {
+			return err
+		}
+	}
+	for i, sink := range c.SMTP {
+		if sink.Addr == "" || sink.From == "" || len(sink.To) == 0 {
+			return fmt.Errorf("notify.smtp[%d]: addr, from and to are required", i)
+		}
+		if err := check("smtp", i, sink.Events); err != nil {
+			return err
+		}
+	}
+	for i, sink := range c.Commands {
+		if sink.Command == "" {
+			return fmt.Errorf("notify.commands[%d]: command is required", i)
+		}
+		if err := check("commands", i, sink.Events); err != nil {
+			return err
+		}
+	}
+	if c.DailySummary != "" {
+		if _, err := time.Parse("15:04", c.DailySummary); err != nil {
+			return fmt.Errorf("notify.dailySummary must look like \"21:00\": %w", err)
+		}
+	}
+	return nil
+}
+
+// withDefaults 填充未配置的默认值
+func (c NotifyConfig) withDefaults() NotifyConfig {
+	if c.WSDownAfter <= 0 {
+		c.WSDownAfter = Duration(10 * time.Minute)
+	}
+	if c.StallWindow <= 0 {
+		c.StallWindow = Duration(2 * time.Hour)
+	}
+	if c.Dedup <= 0 {
+		c.Dedup = Duration(30 * time.Minute)
+	}
+	if c.MaxPerHour <= 0 {
+		c.MaxPerHour = 20
+	}
+	return c
+}
+
+// sinks 根据配置创建通知渠道
+func (c NotifyConfig) sinks() []*notifySink {
+	var sinks []*notifySink
+	for _, sink := range c.Webhooks {
+		sinks = append(sinks, &notifySink{Sink: newWebhookSink(sink), events: sink.Events, target: "webhook " + sink.URL})
+	}
+	for _, sink := range c.SMTP {
+		target := fmt.Sprintf("smtp %s %s %v", sink.Addr, sink.From, sink.To)
+		sinks = append(sinks, &notifySink{Sink: newSMTPSink(sink), events: sink.Events, target: target})
+	}
+	for _, sink := range c.Commands {
+		sinks = append(sinks, &notifySink{Sink: newCommandSink(sink), events: sink.Events, target: "command " + sink.Command})
+	}
+	return sinks
+}
+
+// notifier 通知子系统: 去重、限流并异步发送到各个渠道,同时定期检查断线、积分停滞和每日汇总
+type notifier struct {
+	o *OpenLedger
+
+	mu       sync.Mutex
+	config   NotifyConfig
+	sinks    []*notifySink
+	lastSent map[string]time.Time
+
+	// 定期检查的状态
+	wsDownSince  map[string]time.Time
+	wsDownSent   map[string]bool
+	lastTotal    map[string]Decimal
+	lastIncrease map[string]time.Time
+	stalledSent  map[string]bool
+	summaryDay   string
+
+	queue       chan Notification
+	done        chan struct{}
+	wg          sync.WaitGroup
+	unsubscribe func()
+}
+
+func newNotifier(o *OpenLedger, config NotifyConfig) *notifier {
+	n := &notifier{
+		o:            o,
+		lastSent:     make(map[string]time.Time),
+		wsDownSince:  make(map[string]time.Time),
+		wsDownSent:   make(map[string]bool),
+		lastTotal:    make(map[string]Decimal),
+		lastIncrease: make(map[string]time.Time),
+		stalledSent:  make(map[string]bool),
+		queue:        make(chan Notification, 100),
+		done:         make(chan struct{}),
+	}
+	n.update(config)
+
+	// 启动时已过汇总时间则从明天开始发送
+	now := time.Now()
+	if summaryDue(now, n.config.DailySummary) {
+		n.summaryDay = now.Format("2006-01-02")
+	}
+	return n
+}
+
+// update 应用新的通知配置,目标不变的渠道保留每小时的发送记录
+func (n *notifier) update(config NotifyConfig) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	previous := make(map[string]*notifySink, len(n.sinks))
+	for _, sink := range n.sinks {
+		previous[sink.target] = sink
+	}
+	n.config = config.withDefaults()
+	n.sinks = config.sinks()
+	for _, sink := range n.sinks {
+		if old, ok := previous[sink.target]; ok {
+			sink.sent, sink.warned = old.sent, old.warned
+		}
+	}
+}
+
+func (n *notifier) sinkCount() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return len(n.sinks)
+}
+
+func (n *notifier) start() {
+	n.wg.Add(2)
+	go n.sendLoop()
+	go n.monitorLoop()
+}
+
+func (n *notifier) close() {
+	if n.unsubscribe != nil {
+		n.unsubscribe()
+	}
+	close(n.done)
+	n.wg.Wait()
+}
+
+// notify 脱敏、遮盖钱包地址、去重后加入发送队列,队列满时丢弃
+// 同一账号的同一事件在 Dedup 时长内只发送一次,断线反复或错误信息变化时不会重复通知
+func (n *notifier) notify(event, account, title, message string) {
+	n.notifyKeyed(event+"|"+account, event, account, title, message)
+}
+
+// notifyKeyed 按指定的去重键发送,同一事件可能对应多个对象(如多个等级)时使用
+func (n *notifier) notifyKeyed(key, event, account, title, message string) {
+	now := time.Now()
+	title, message = Redact(n.o.maskAddresses(title)), Redact(n.o.maskAddresses(message))
+
+	n.mu.Lock()
+	dedup := time.Duration(n.config.Dedup)
+	if last, ok := n.lastSent[key]; ok && now.Sub(last) < dedup {
+		n.mu.Unlock()
+		return
+	}
+	// 插入时清理过期的记录,避免长时间运行后无限增长
+	for k, last := range n.lastSent {
+		if now.Sub(last) >= dedup {
+			delete(n.lastSent, k)
+		}
+	}
+	n.lastSent[key] = now
+	n.mu.Unlock()
+
+	select {
+	case n.queue <- Notification{Event: event, Account: account, Title: title, Message: message, Time: now}:
+	default:
+		n.o.log(color.YellowString("Notification queue is full, dropped %s", event))
+	}
+}
+
+// sendLoop 逐条发送通知,单个渠道失败不影响其他渠道
+func (n *notifier) sendLoop() {
+	defer n.wg.Done()
+	for {
+		select {
+		case <-n.done:
+			n.drain(time.Now().Add(notifyDrainTimeout))
+			return
+		case notification := <-n.queue:
+			n.send(notification)
+		}
+	}
+}
+
+// drain 退出前发送队列中剩余的通知,超过截止时间后丢弃其余通知
+func (n *notifier) drain(deadline time.Time) {
+	for time.Now().Before(deadline) {
+		select {
+		case notification := <-n.queue:
+			n.send(notification)
+		default:
+			return
+		}
+	}
+	if dropped := len(n.queue); dropped > 0 {
+		n.o.log(color.YellowString("Notifier closed, dropped %d queued notification(s)", dropped))
+	}
+}
+
+func (n *notifier) send(notification Notification) {
+	n.mu.Lock()
+	limit := n.config.MaxPerHour
+	var targets []*notifySink
+	for _, sink := range n.sinks {
+		if !sink.events.accepts(notification.Event) {
+			continue
+		}
+		if !sink.allow(notification.Time, limit) {
+			if !sink.warned {
+				sink.warned = true
+				n.o.log(color.YellowString("Notification sink %s reached %d per hour, dropping until the limit resets", sink.Name(), limit))
+			}
+			continue
+		}
+		targets = append(targets, sink)
+	}
+	n.mu.Unlock()
+
+	for _, sink := range targets {
+		if err := sink.Send(notification); err != nil {
+			n.o.log(color.RedString("Notification via %s failed: %v", sink.Name(), err))
+		}
+	}
+}
+
+// summaryDue 判断当天是否已到汇总时间,按时和分比较,"9:00" 与 "09:00" 相同
+func summaryDue(now time.Time, at string) bool {
+	if at == "" {
+		return false
+	}
+	t, err := time.Parse("15:04", at)
+	if err != nil {
+		return false
+	}
+	return now.Hour()*60+now.Minute() >= t.Hour()*60+t.Minute()
+}
+
+// monitorLoop 定期检查需要持续观察的事件
+func (n *notifier) monitorLoop() {
+	defer n.wg.Done()
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-n.done:
+			return
+		case now := <-ticker.C:
+			n.check(now)
+		}
+	}
+}
+
+// check 检查WebSocket断线、积分停滞和每日汇总
+func (n *notifier) check(now time.Time) {
+	n.mu.Lock()
+	config := n.config
+	n.mu.Unlock()
+
+	snapshots := n.o.snapshots()
+	for _, s := range snapshots {
+		account := s.Account
+		name := n.o.displayName(account)
+		if s.Paused {
+			delete(n.wsDownSince, account)
+			continue
+		}
+
+		// WebSocket长时间未注册
+		if n.o.accountConfig(account).Tasks.WebSocket {
+			if s.WSStatus == wsStatusRegistered {
+				delete(n.wsDownSince, account)
+				delete(n.wsDownSent, account)
+			} else if since, ok := n.wsDownSince[account]; !ok {
+				n.wsDownSince[account] = now
+			} else if now.Sub(since) >= time.Duration(config.WSDownAfter) && !n.wsDownSent[account] {
+				n.wsDownSent[account] = true
+				n.notify(EventWSDown, name, "WebSocket down",
+					fmt.Sprintf("Account %s WebSocket has not been registered for over %s", name, time.Duration(config.WSDownAfter)))
+			}
+		}
+
+		// 总积分长时间没有增加
+		if s.TotalPoint != nil {
+			last, seen := n.lastTotal[account]
+			if !seen || s.TotalPoint.Cmp(last) > 0 {
+				n.lastTotal[account] = *s.TotalPoint
+				n.lastIncrease[account] = now
+				delete(n.stalledSent, account)
+			} else if now.Sub(n.lastIncrease[account]) >= time.Duration(config.StallWindow) && !n.stalledSent[account] {
+				n.stalledSent[account] = true
+				n.notify(EventPointsStalled, name, "Points not increasing",
+					fmt.Sprintf("Account %s total points stayed at %s PTS for over %s", name, s.TotalPoint.StringFixed(2), time.Duration(config.StallWindow)))
+			}
+		}
+	}
+
+	// 每日汇总
+	if summaryDue(now, config.DailySummary) && n.summaryDay != now.Format("2006-01-02") {
+		n.summaryDay = now.Format("2006-01-02")
+		n.notify(EventDailySummary, "", "Daily summary", n.summary(snapshots))
+	}
+}
+
+// summary 所有账号的积分和状态汇总
+func (n *notifier) summary(snapshots []accountSnapshot) string {
+	var b strings.Builder
+	total := Decimal{}
+	for _, s := range snapshots {
+		if s.TotalPoint != nil {
+			total = total.Add(*s.TotalPoint)
+		}
+		fmt.Fprintf(&b, "%s: total %s PTS, %s heartbeats today, websocket %s, check-in %s\n",
+			n.o.displayName(s.Account), formatPoints(s.TotalPoint), formatCount(s.Earnings.TodayHeartbeats), s.WSStatus, s.CheckinState)
+	}
+	fmt.Fprintf(&b, "%d account(s), %s PTS in total", len(snapshots), total.StringFixed(2))
+	return b.String()
+}
+
+// handleEvent 将需要通知的生命周期事件转换为通知
+func (n *notifier) handleEvent(e Event) {
+	o := n.o
+	switch e := e.(type) {
+	case *AuthFailed:
+		action := "generate"
+		if e.Renewal {
+			action = "renew"
+		}
+		name := o.displayName(e.Account)
+		n.notify(EventAuthFailed, name, "Authentication failed",
+			fmt.Sprintf("Account %s failed to %s its access token: %v", name, action, e.Err))
+
+	case *CheckinClaimed:
+		name := o.displayName(e.Account)
+		n.notify(EventCheckinClaimed, name, "Check-in claimed",
+			fmt.Sprintf("Account %s claimed the daily check-in reward of %s PTS", name, e.Points.StringFixed(2)))
+
+	case *TierClaimed:
+		name := o.displayName(e.Account)
+		n.notifyKeyed(EventTierClaimed+"|"+name+"|"+e.Tier, EventTierClaimed, name, "Tier claimed",
+			fmt.Sprintf("Account %s claimed tier %s with a reward of %s PTS", name, e.Tier, e.Points.StringFixed(2)))
+
+	case *AccountSuspended:
+		name := o.displayName(e.Account)
+		n.notify(EventAccountSuspended, name, "Account suspended",
+			fmt.Sprintf("Account %s was suspended after %d consecutive failures: %v", name, e.Failures, e.Err))
+
+	case *HeartbeatsReconciled:
+		if e.Dropped {
+			name := o.displayName(e.Account)
+			n.notify(EventHeartbeatsDropped, name, "Heartbeats not credited",
+				fmt.Sprintf("Account %s had %d heartbeats acknowledged but only %s credited since the last check", name, e.Acked, e.credits()))
+		}
+	}
+}
+
+// startNotifier 启动通知子系统,未配置通知渠道时也会启动,以便重新加载时添加
+func (o *OpenLedger) startNotifier() {
+	o.notifier = newNotifier(o, o.settings().Notify)
+	o.notifier.start()
+	o.notifier.unsubscribe = o.Subscribe(o.notifier.handleEvent)
+	if count := o.notifier.sinkCount(); count > 0 {
+		o.log(color.GreenString("Notifications: ") + color.WhiteString("%d sink(s)", count))
+	}
+}
@@ -0,0 +1,182 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// notifyTimeout 单次发送通知的超时时间
+const notifyTimeout = 30 * time.Second
+
+// WebhookSinkConfig 以JSON POST方式发送通知
+type WebhookSinkConfig struct {
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	Events  []string          `json:"events"`
+}
+
+// SMTPSinkConfig 通过SMTP发送邮件通知
+type SMTPSinkConfig struct {
+	// Addr 服务器地址,如 "smtp.example.com:587"
+	Addr     string   `json:"addr"`
+	Username string   `json:"username"`
+	Password string   `json:"password"`
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+	Events   []string `json:"events"`
+}
+
+// CommandSinkConfig 执行命令发送通知,通知内容通过环境变量和标准输入(JSON)传入
+type CommandSinkConfig struct {
+	Command string   `json:"command"`
+	Events  []string `json:"events"`
+}
+
+type webhookSink struct {
+	config WebhookSinkConfig
+	client *http.Client
+}
+
+func newWebhookSink(config WebhookSinkConfig) *webhookSink {
+	return &webhookSink{config: config, client: &http.Client{Timeout: notifyTimeout}}
+}
+
+// Name 只包含协议和主机,Slack、Discord 等的webhook地址路径中带有密钥
+func (s *webhookSink) Name() string {
+	u, err := url.Parse(s.config.URL)
+	if err != nil || u.Host == "" {
+		return "webhook"
+	}
+	return "webhook " + u.Scheme + "://" + u.Host
+}
+
+func (s *webhookSink) Send(n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", s.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range s.config.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := s.client.Do(req)
+	// *url.Error 的信息中包含完整地址,只保留底层错误
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return fmt.Errorf("%s %s: %w", urlErr.Op, s.Name(), urlErr.Err)
+	}
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+type smtpSink struct {
+	config SMTPSinkConfig
+}
+
+func newSMTPSink(config SMTPSinkConfig) *smtpSink {
+	return &smtpSink{config: config}
+}
+
+func (s *smtpSink) Name() string {
+	return "smtp " + s.config.Addr
+}
+
+func (s *smtpSink) Send(n Notification) error {
+	var auth smtp.Auth
+	if s.config.Username != "" {
+		host := s.config.Addr
+		if i := strings.LastIndex(host, ":"); i >= 0 {
+			host = host[:i]
+		}
+		auth = smtp.PlainAuth("", s.config.Username, s.config.Password, host)
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", s.config.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(s.config.To, ", "))
+	fmt.Fprintf(&msg, "Subject: [OpenLedger] %s\r\n", notificationSubject(n))
+	fmt.Fprintf(&msg, "Date: %s\r\n", n.Time.Format(time.RFC1123Z))
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(n.Message, "\n", "\r\n"))
+	msg.WriteString("\r\n")
+
+	return smtp.SendMail(s.config.Addr, auth, s.config.From, s.config.To, msg.Bytes())
+}
+
+// notificationSubject 邮件主题,包含账号名称
+func notificationSubject(n Notification) string {
+	if n.Account == "" {
+		return n.Title
+	}
+	return fmt.Sprintf("%s - %s", n.Title, n.Account)
+}
+
+type commandSink struct {
+	config CommandSinkConfig
+}
+
+func newCommandSink(config CommandSinkConfig) *commandSink {
+	return &commandSink{config: config}
+}
+
+func (s *commandSink) Name() string {
+	return "command " + s.config.Command
+}
+
+func (s *commandSink) Send(n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", s.config.Command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", s.config.Command)
+	}
+	cmd.Env = append(os.Environ(),
+		"OPENLEDGER_EVENT="+n.Event,
+		"OPENLEDGER_ACCOUNT="+n.Account,
+		"OPENLEDGER_TITLE="+n.Title,
+		"OPENLEDGER_MESSAGE="+n.Message,
+	)
+	cmd.Stdin = bytes.NewReader(body)
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		if text := strings.TrimSpace(string(output)); text != "" {
+			return fmt.Errorf("%w: %s", err, text)
+		}
+		return err
+	}
+	return nil
+}
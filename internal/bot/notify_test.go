@@ -0,0 +1,70 @@
+package bot
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingSink 记录收到的通知,每次发送前等待 delay
+type recordingSink struct {
+	mu    sync.Mutex
+	delay time.Duration
+	sent  []Notification
+}
+
+func (s *recordingSink) Name() string { return "recording" }
+
+func (s *recordingSink) Send(n Notification) error {
+	time.Sleep(s.delay)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, n)
+	return nil
+}
+
+func (s *recordingSink) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.sent)
+}
+
+func TestSummaryDue(t *testing.T) {
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local)
+	tests := []struct {
+		now  string
+		at   string
+		want bool
+	}{
+		{"10:00", "9:00", true},
+		{"09:00", "9:00", true},
+		{"08:59", "9:00", false},
+		{"21:30", "21:00", true},
+		{"20:59", "21:00", false},
+		{"10:00", "", false},
+	}
+	for _, tt := range tests {
+		clock, _ := time.Parse("15:04", tt.now)
+		now := day.Add(time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute)
+		if got := summaryDue(now, tt.at); got != tt.want {
+			t.Errorf("summaryDue(%s, %q) = %v, want %v", tt.now, tt.at, got, tt.want)
+		}
+	}
+}
+
+func TestNotifierCloseDrainsQueue(t *testing.T) {
+	o := &OpenLedger{logger: &Logger{output: func(string) {}}}
+	n := newNotifier(o, NotifyConfig{})
+	sink := &recordingSink{delay: 20 * time.Millisecond}
+	n.sinks = []*notifySink{{Sink: sink, target: "recording"}}
+	n.start()
+
+	for _, account := range []string{"a", "b", "c", "d", "e"} {
+		n.notify(EventCheckinClaimed, account, "Check-in claimed", "claimed")
+	}
+	n.close()
+
+	if got := sink.count(); got != 5 {
+		t.Errorf("sent %d notifications before close returned, want 5", got)
+	}
+}
@@ -2,17 +2,19 @@ package bot
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/fatih/color"
 )
 
-// processAccount 处理单个账号
-func (o *OpenLedger) processAccount(account string, useProxy bool) {
+// processAccount 处理单个账号,运行时状态已由 startAccount 登记
+func (o *OpenLedger) processAccount(rt *accountRuntime, useProxy bool) {
 	defer o.wg.Done()
+	account := rt.account
 
 	o.log(fmt.Sprintf("%s Starting process for account: %s",
 		color.CyanString("["),
-		color.WhiteString(o.hideAccount(account))))
+		color.WhiteString(o.displayName(account))))
 
 	var proxy string
 	if useProxy {
@@ -20,56 +22,120 @@ func (o *OpenLedger) processAccount(account string, useProxy bool) {
 		o.log(fmt.Sprintf("%s Using proxy: %s for account: %s",
 			color.CyanString("["),
 			color.YellowString(proxy),
-			color.WhiteString(o.hideAccount(account))))
+			color.WhiteString(o.displayName(account))))
 	}
 
-	// 生成初始token
-	token, err := o.generateToken(account, proxy)
-	if err != nil {
-		o.log(fmt.Sprintf("%s Account %s - Failed to generate initial token: %v",
-			color.RedString("✗"),
-			color.WhiteString(o.hideAccount(account)),
-			err))
+	// 生成初始token,失败时按退避间隔重试
+	if !o.authenticate(rt, proxy) {
 		return
 	}
 
-	o.log(fmt.Sprintf("%s Account %s - Token generated successfully",
-		color.GreenString("✓"),
-		color.WhiteString(o.hideAccount(account))))
-
 	// 创建错误通道
 	errChan := make(chan error, 4)
-	defer close(errChan)
 
-	// 创建done通道用于控制goroutine退出
-	doneChan := make(chan struct{})
-	defer close(doneChan)
+	// 按账号配置启动各个功能的goroutine,任务panic时由supervise重启
+	tasks := o.accountConfig(account).Tasks
+	var workers sync.WaitGroup
+	workers.Add(tasks.count())
+	if tasks.Earning {
+		go func() {
+			defer workers.Done()
+			o.supervise(rt, "earning", func() {
+				o.ProcessUserEarning(account, proxy, errChan)
+			})
+		}()
+	}
+	if tasks.Checkin {
+		go func() {
+			defer workers.Done()
+			o.supervise(rt, "checkin", func() {
+				o.processCheckin(account, proxy, errChan)
+			})
+		}()
+	}
+	if tasks.Tier {
+		go func() {
+			defer workers.Done()
+			o.supervise(rt, "tier", func() {
+				o.processClaimTier(account, proxy, errChan)
+			})
+		}()
+	}
+	if tasks.WebSocket {
+		go func() {
+			defer workers.Done()
+			o.supervise(rt, "websocket", func() {
+				o.processWebSocket(account, useProxy, proxy, errChan)
+			})
+		}()
+
+		// 心跳对账只在发送心跳时有意义
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			o.supervise(rt, "reconcile", func() {
+				o.processReconcile(account, proxy, errChan)
+			})
+		}()
+	}
 
-	// 启动各个功能的goroutine
-	go o.ProcessUserEarning(account, token, proxy, errChan)
-	go o.processCheckin(account, token, proxy, errChan)
-	go o.processClaimTier(account, token, proxy, errChan)
-	go o.processWebSocket(account, token, useProxy, proxy, errChan)
+	// 所有任务退出后关闭错误通道
+	go func() {
+		workers.Wait()
+		close(errChan)
+	}()
 
 	o.log(fmt.Sprintf("%s Account %s - All processes started",
 		color.GreenString("✓"),
-		color.WhiteString(o.hideAccount(account))))
+		color.WhiteString(o.displayName(account))))
 
-	// 监听错误
+	// 监听错误和控制命令
 	for {
 		select {
 		case err, ok := <-errChan:
 			if !ok {
+				o.log(fmt.Sprintf("%s Account %s - All processes stopped",
+					color.YellowString("!"),
+					color.WhiteString(o.displayName(account))))
 				return
 			}
 			if err != nil {
 				o.log(fmt.Sprintf("%s Account %s - Error: %v",
 					color.RedString("✗"),
-					color.WhiteString(o.hideAccount(account)),
+					color.WhiteString(o.displayName(account)),
 					err))
+				o.transition(rt, StateDegraded, redactError(err))
 			}
-		case <-doneChan:
-			return
+		case <-rt.renew:
+			o.runTask(account, "renew", func() error {
+				o.renewToken(account, proxy)
+				return nil
+			})
+		}
+	}
+}
+
+// authenticate 生成初始令牌,失败时按退避间隔重试,账号停止时返回false
+func (o *OpenLedger) authenticate(rt *accountRuntime, proxy string) bool {
+	for o.active(rt) {
+		o.transition(rt, StateAuthenticating, "generating token")
+
+		var token string
+		err := o.runTask(rt.account, "auth", func() error {
+			var err error
+			token, err = o.generateToken(rt.account, proxy)
+			return err
+		})
+		if err == nil {
+			o.emit(rt.account, &TokenGenerated{ExpiresAt: expiresAt(token)})
+			return true
+		}
+
+		o.emit(rt.account, &AuthFailed{Err: err})
+		if !o.active(rt) {
+			return false
 		}
+		o.restartAfter(rt, "auth", err)
 	}
+	return false
 }
@@ -14,33 +14,57 @@ import (
 // getProxyChoice 获取用户代理选择
 func (o *OpenLedger) getProxyChoice() (int, error) {
 	for {
-		fmt.Println("1. Run With Auto Proxy")
-		fmt.Println("2. Run With Manual Proxy") 
-		fmt.Println("3. Run Without Proxy")
-		fmt.Print("Choose [1/2/3] -> ")
+		fmt.Fprintln(o.output, "1. Run With Auto Proxy")
+		fmt.Fprintln(o.output, "2. Run With Manual Proxy") 
+		fmt.Fprintln(o.output, "3. Run Without Proxy")
+		fmt.Fprint(o.output, "Choose [1/2/3] -> ")
 
 		var choice int
 		var input string
-		fmt.Scanln(&input)
+		if _, err := fmt.Scanln(&input); err == io.EOF {
+			return 0, fmt.Errorf("standard input closed, set proxy in the config or with --proxy")
+		}
 		_, err := fmt.Sscanf(input, "%d", &choice)
 		if err != nil {
-			fmt.Printf("%s Please enter a number (1, 2 or 3).\n", 
+			fmt.Fprintf(o.output, "%s Please enter a number (1, 2 or 3).\n", 
 				color.RedString("Invalid input."))
 			continue
 		}
 
 		if choice >= 1 && choice <= 3 {
-			fmt.Printf("%s Run %s Selected.\n",
+			fmt.Fprintf(o.output, "%s Run %s Selected.\n",
 				color.GreenString("✓"),
 				color.WhiteString(o.getProxyTypeString(choice)))
 			return choice, nil
 		}
 
-		fmt.Printf("%s Please enter either 1, 2 or 3.\n",
+		fmt.Fprintf(o.output, "%s Please enter either 1, 2 or 3.\n",
 			color.RedString("Invalid choice."))
 	}
 }
 
+// proxySources 配置中的代理来源与菜单选项的对应关系
+var proxySources = map[string]int{
+	"auto":   1,
+	"manual": 2,
+	"none":   3,
+}
+
+// proxyChoice 配置了代理来源时直接使用,否则交互式询问
+func (o *OpenLedger) proxyChoice() (int, error) {
+	source := o.settings().Proxy
+	if source == "" {
+		return o.getProxyChoice()
+	}
+
+	choice, ok := proxySources[source]
+	if !ok {
+		return 0, fmt.Errorf("unknown proxy source %q (expected auto, manual or none)", source)
+	}
+	o.log(fmt.Sprintf("%s Run %s Selected.", color.GreenString("✓"), color.WhiteString(o.getProxyTypeString(choice))))
+	return choice, nil
+}
+
 // getProxyTypeString 获取代理类型字符串
 func (o *OpenLedger) getProxyTypeString(choice int) string {
 	switch choice {
@@ -0,0 +1,216 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"openledger/pkg/openledger"
+)
+
+// 对账参数
+const (
+	// reconcileTolerance 窗口内确认的心跳中允许未计入的比例,服务端计数可能有延迟
+	reconcileTolerance = 0.05
+	// reconcileMinGap 未计入的心跳至少达到该数量才报告
+	reconcileMinGap = 2
+	// reconcileDays 保留的每日对账记录天数
+	reconcileDays = 7
+)
+
+// ReconcileDay 一天(UTC)内各对账窗口的合计
+type ReconcileDay struct {
+	Acked    int64 `json:"acked"`
+	Credited int64 `json:"credited"`
+}
+
+// ReconcileResult 一次心跳对账的结果: 比较上次对账以来本地确认的心跳数 Acked 与服务端各个计数的增加量
+type ReconcileResult struct {
+	// Day 对账时的UTC日期
+	Day   string `json:"day"`
+	Acked int64  `json:"acked"`
+	// Credited heartbeat_count 的增加量,计数重置时为重置后的计数
+	Credited int64 `json:"credited"`
+	// TotalCredited/RealtimeCredited total_heartbeats 和 reward_realtime 的增加量,第一次对账、计数变小或未知时为nil
+	TotalCredited    *int64 `json:"totalCredited"`
+	RealtimeCredited *int64 `json:"realtimeCredited"`
+	// Today 当天各窗口的合计
+	Today ReconcileDay `json:"today"`
+	// HeartbeatCount/TotalHeartbeats 为 worker_reward 的返回值,RealtimeHeartbeats 为 reward_realtime 的 total_heartbeats,未知时为nil
+	HeartbeatCount     Decimal  `json:"heartbeatCount"`
+	TotalHeartbeats    Decimal  `json:"totalHeartbeats"`
+	RealtimeHeartbeats *Decimal `json:"realtimeHeartbeats"`
+	// Baseline 第一次对账,只记录基准
+	Baseline bool `json:"baseline"`
+	// Reset heartbeat_count 已重置(跨日),只按其余计数判断
+	Reset bool `json:"reset"`
+	// Dropped 任一服务端计数中未计入的心跳超过容差
+	Dropped bool `json:"dropped"`
+}
+
+// credits 各个服务端计数的增加量,用于日志和通知
+func (r ReconcileResult) credits() string {
+	parts := []string{fmt.Sprintf("heartbeat_count %d", r.Credited)}
+	if r.TotalCredited != nil {
+		parts = append(parts, fmt.Sprintf("total_heartbeats %d", *r.TotalCredited))
+	}
+	if r.RealtimeCredited != nil {
+		parts = append(parts, fmt.Sprintf("reward_realtime %d", *r.RealtimeCredited))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// reconcileState 心跳对账的状态,由 accountRuntime.mu 保护
+type reconcileState struct {
+	// prevAcked/prevCredited 上次对账时本地确认的心跳总数和服务端的 heartbeat_count
+	prevAcked    int64
+	prevCredited *int64
+	// prevTotal/prevRealtime 上次对账时的 total_heartbeats 和 reward_realtime,未知时为nil
+	prevTotal    *int64
+	prevRealtime *int64
+	days         map[string]ReconcileDay
+	last         *ReconcileResult
+}
+
+// reconcileDay 对账记录使用的日期
+func reconcileDay(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
+
+// reconcile 用服务端的 heartbeat_count、total_heartbeats 和 reward_realtime 与上次对账以来本地确认的心跳数比较
+// 第一次对账只记录基准;计数变小说明服务端已跨日重置,该计数在这个窗口不做判断
+func (rt *accountRuntime) reconcile(now time.Time, count, total Decimal, realtimeTotal *Decimal) ReconcileResult {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	r := &rt.reconciliation
+	acked := rt.heartbeats.totalAcked
+	credited := int64(count.Float64())
+	totalCount := int64(total.Float64())
+	var realtime *int64
+	if realtimeTotal != nil {
+		v := int64(realtimeTotal.Float64())
+		realtime = &v
+	}
+	report := &ReconcileResult{
+		Day:                reconcileDay(now),
+		HeartbeatCount:     count,
+		TotalHeartbeats:    total,
+		RealtimeHeartbeats: realtimeTotal,
+	}
+
+	switch {
+	case r.prevCredited == nil:
+		report.Baseline = true
+	case credited < *r.prevCredited:
+		report.Reset = true
+		report.Acked = acked - r.prevAcked
+		report.Credited = credited
+	default:
+		report.Acked = acked - r.prevAcked
+		report.Credited = credited - *r.prevCredited
+		report.Dropped = shortfall(report.Acked, report.Credited)
+	}
+	if !report.Baseline {
+		report.TotalCredited = counterDelta(r.prevTotal, &totalCount)
+		report.RealtimeCredited = counterDelta(r.prevRealtime, realtime)
+		for _, delta := range []*int64{report.TotalCredited, report.RealtimeCredited} {
+			if delta != nil && shortfall(report.Acked, *delta) {
+				report.Dropped = true
+			}
+		}
+	}
+	r.prevAcked = acked
+	r.prevCredited = &credited
+	r.prevTotal = &totalCount
+	r.prevRealtime = realtime
+
+	if !report.Baseline {
+		if r.days == nil {
+			r.days = make(map[string]ReconcileDay)
+		}
+		day := r.days[report.Day]
+		day.Acked += report.Acked
+		day.Credited += report.Credited
+		r.days[report.Day] = day
+		for name := range r.days {
+			if name < reconcileDay(now.AddDate(0, 0, -reconcileDays+1)) {
+				delete(r.days, name)
+			}
+		}
+	}
+	report.Today = r.days[report.Day]
+
+	r.last = report
+	return *report
+}
+
+// counterDelta 服务端计数的增加量,上次或本次未知、或计数变小(已重置)时为nil
+func counterDelta(prev, current *int64) *int64 {
+	if prev == nil || current == nil || *current < *prev {
+		return nil
+	}
+	delta := *current - *prev
+	return &delta
+}
+
+// shortfall 判断服务端计数的增加量是否比确认的心跳数少得超过容差
+func shortfall(acked, credited int64) bool {
+	missing := acked - credited
+	return missing >= reconcileMinGap && float64(missing) > reconcileTolerance*float64(acked)
+}
+
+// reconcileSnapshotLocked 返回最近一次对账结果和每日合计的副本,调用方需持有 rt.mu
+func (rt *accountRuntime) reconcileSnapshotLocked() (*ReconcileResult, map[string]ReconcileDay) {
+	r := &rt.reconciliation
+	if r.last == nil {
+		return nil, nil
+	}
+	last := *r.last
+	days := make(map[string]ReconcileDay, len(r.days))
+	for name, day := range r.days {
+		days[name] = day
+	}
+	return &last, days
+}
+
+// reconcileHeartbeats 查询 worker_reward 和 reward_realtime,与本地确认的心跳对账
+func (o *OpenLedger) reconcileHeartbeats(account, proxy string) error {
+	rt := o.runtime(account)
+
+	var worker *openledger.WorkerRewardResponse
+	err := o.callAPI(account, proxy, func(client *openledger.Client, token string) (err error) {
+		worker, err = client.WorkerReward(context.Background(), token)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("get worker reward failed: %w", err)
+	}
+
+	realtimeTotal, err := o.getRealtimeReward(account, proxy)
+	if err != nil {
+		return fmt.Errorf("get realtime reward failed: %w", err)
+	}
+
+	var count, total Decimal
+	if len(worker.Data) > 0 {
+		count, total = worker.Data[0].HeartbeatCount, worker.Data[0].TotalHeartbeats
+	}
+
+	o.emit(account, &HeartbeatsReconciled{ReconcileResult: rt.reconcile(time.Now(), count, total, realtimeTotal)})
+	return nil
+}
+
+// processReconcile 按配置的间隔对账,第一次对账只记录基准
+func (o *OpenLedger) processReconcile(account, proxy string, errChan chan<- error) {
+	rt := o.runtime(account)
+	for o.active(rt) {
+		o.waitIfPaused(rt)
+		if err := o.reconcileHeartbeats(account, proxy); err != nil {
+			errChan <- fmt.Errorf("heartbeat reconciliation failed: %w", err)
+		}
+
+		rt.sleep(time.Duration(o.settings().ReconcileInterval), nil)
+	}
+}
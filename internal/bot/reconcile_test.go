@@ -0,0 +1,43 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"openledger/pkg/openledger"
+)
+
+func TestReconcileComparesEveryCounter(t *testing.T) {
+	rt := newAccountRuntime(eip55Vectors[0])
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	realtime := func(n int64) *Decimal {
+		d := openledger.DecimalFromInt(n)
+		return &d
+	}
+
+	steps := []struct {
+		name     string
+		acked    int64
+		count    int64
+		total    int64
+		realtime *Decimal
+		dropped  bool
+		reset    bool
+	}{
+		{"baseline", 0, 10, 100, realtime(10), false, false},
+		{"all counters credited", 20, 30, 120, realtime(30), false, false},
+		{"total_heartbeats short", 40, 50, 125, realtime(50), true, false},
+		{"reward_realtime short", 60, 70, 145, realtime(55), true, false},
+		{"reward_realtime unknown", 80, 90, 165, nil, false, false},
+		// heartbeat_count 跨日重置后仍按 total_heartbeats 判断
+		{"heartbeat_count reset", 100, 5, 170, realtime(5), true, true},
+	}
+	for _, step := range steps {
+		rt.heartbeats.totalAcked = step.acked
+		result := rt.reconcile(now, openledger.DecimalFromInt(step.count), openledger.DecimalFromInt(step.total), step.realtime)
+		if result.Dropped != step.dropped || result.Reset != step.reset {
+			t.Errorf("%s: dropped=%v reset=%v, want dropped=%v reset=%v (credited %s)",
+				step.name, result.Dropped, result.Reset, step.dropped, step.reset, result.credits())
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package bot
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/fatih/color"
+
+	"openledger/internal/cassette"
+	"openledger/pkg/openledger"
+)
+
+// 录制和日志中需要脱敏的令牌
+var (
+	jwtPattern        = regexp.MustCompile(`eyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	tokenFieldPattern = regexp.MustCompile(`("token"\s*:\s*")[^"]*`)
+	authTokenPattern  = regexp.MustCompile(`(authToken=)[^&\s"]*`)
+)
+
+// SetTransport 替换未使用代理时的HTTP传输层,例如回放录制的请求
+func (o *OpenLedger) SetTransport(transport http.RoundTripper) {
+	o.transport = transport
+}
+
+// openRecorder 配置了录制目录时开始录制
+func (o *OpenLedger) openRecorder() error {
+	dir := o.settings().Record
+	if dir == "" || o.recorder != nil {
+		return nil
+	}
+
+	recorder, err := cassette.NewRecorder(dir)
+	if err != nil {
+		return err
+	}
+	o.recorder = recorder
+	o.log(color.MagentaString("Recording traffic to ") + color.WhiteString(dir))
+	return nil
+}
+
+// cassetteName 账号的录制文件名,使用带密钥的假名,不持有密钥时无法由地址确认对应的文件
+func (o *OpenLedger) cassetteName(account string) string {
+	return "acct-" + o.pseudonym(strings.ToLower(account))
+}
+
+// cassetteRedactor 替换录制内容中的令牌、钱包地址和Worker ID,每个账号只创建一次
+func (o *OpenLedger) cassetteRedactor(account string) cassette.Redactor {
+	o.redactorMutex.Lock()
+	defer o.redactorMutex.Unlock()
+
+	if redact, ok := o.redactors[account]; ok {
+		return redact
+	}
+	address := regexp.MustCompile(`(?i)` + regexp.QuoteMeta(account))
+	worker := openledger.WorkerID(account)
+	redact := func(s string) string {
+		s = Redact(s)
+		s = address.ReplaceAllString(s, "<account>")
+		return strings.ReplaceAll(s, worker, "<worker>")
+	}
+	if o.redactors == nil {
+		o.redactors = make(map[string]cassette.Redactor)
+	}
+	o.redactors[account] = redact
+	return redact
+}
+
+// httpTransport 返回账号请求使用的传输层,录制模式下记录所有请求
+func (o *OpenLedger) httpTransport(account string, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = o.transport
+	}
+	if o.recorder == nil {
+		return base
+	}
+	return o.recorder.Transport(o.cassetteName(account), base, o.cassetteRedactor(account))
+}
+
+// recordFrame 录制模式下记录一条WebSocket消息
+func (o *OpenLedger) recordFrame(account, direction string, data []byte) {
+	if o.recorder != nil {
+		o.recorder.RecordFrame(o.cassetteName(account), direction, data, o.cassetteRedactor(account))
+	}
+}
@@ -0,0 +1,31 @@
+package bot
+
+import (
+	"regexp"
+)
+
+// 日志和错误中需要遮盖的密钥,令牌的模式与录制共用
+var (
+	// proxyCredentialPattern 代理URL中的 user:password@,只匹配带协议的代理地址
+	// 没有协议的代理在使用前已由 checkProxySchemes 补全为 http://
+	proxyCredentialPattern = regexp.MustCompile(`(?i)((?:https?|socks[45]h?)://)[^\s/@"']+@`)
+	bearerPattern          = regexp.MustCompile(`(?i)(Bearer\s+)[^\s"',;]+`)
+)
+
+// Redact 遮盖文本中的代理账号密码、Bearer令牌、authToken参数和JWT
+// 所有日志行、通知和对外展示的错误信息都经过这里
+func Redact(s string) string {
+	s = proxyCredentialPattern.ReplaceAllString(s, "${1}<redacted>@")
+	s = bearerPattern.ReplaceAllString(s, "${1}<token>")
+	s = authTokenPattern.ReplaceAllString(s, "${1}<token>")
+	s = tokenFieldPattern.ReplaceAllString(s, "${1}<token>")
+	return jwtPattern.ReplaceAllString(s, "<token>")
+}
+
+// redactError 返回脱敏后的错误信息,err为nil时返回空字符串
+func redactError(err error) string {
+	if err == nil {
+		return ""
+	}
+	return Redact(err.Error())
+}
@@ -0,0 +1,223 @@
+package bot
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"openledger/internal/cassette"
+	"openledger/pkg/openledger"
+)
+
+const replayAddress = "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359"
+
+// roundTripFunc 以函数实现 http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// fakeAPI 按路径返回固定的JSON响应,代替真实服务器
+func fakeAPI(responses map[string]string) http.RoundTripper {
+	return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		body, ok := responses[req.URL.Path]
+		status := http.StatusOK
+		if !ok {
+			status, body = http.StatusNotFound, `{"message":"not found"}`
+		}
+		return &http.Response{
+			StatusCode: status,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}, nil
+	})
+}
+
+// fakeAcker 记录回放时确认的任务
+type fakeAcker struct {
+	jobs []interface{}
+}
+
+func (a *fakeAcker) AckJob(ref interface{}) error {
+	a.jobs = append(a.jobs, ref)
+	return nil
+}
+
+// capturedLogs 收集日志行
+type capturedLogs struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (c *capturedLogs) add(line string) {
+	c.mu.Lock()
+	c.lines = append(c.lines, line)
+	c.mu.Unlock()
+}
+
+func (c *capturedLogs) contains(s string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, line := range c.lines {
+		if strings.Contains(line, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// newReplayBot 指向不存在的服务器的bot,请求只能由传输层应答
+func newReplayBot(logs *capturedLogs) *OpenLedger {
+	config := DefaultConfig()
+	config.Profile = "replay"
+	config.Profiles = map[string]Endpoints{"replay": {
+		Auth:         "http://auth.invalid",
+		Rewards:      "http://rewards.invalid",
+		Orchestrator: "ws://orch.invalid/ws/v1/orch",
+	}}
+	// 固定的假名密钥,录制文件名不依赖工作目录中的密钥文件
+	config.PseudonymKey = "replay-test"
+	o := &OpenLedger{
+		config:   config,
+		logger:   &Logger{output: logs.add},
+		runtimes: make(map[string]*accountRuntime),
+		schemas:  newSchemaTracker(),
+		events:   newEventBus(),
+	}
+	o.Subscribe(o.trackEvent)
+	o.Subscribe(o.logEvent)
+	// 登记运行时状态,令牌由账号的令牌管理器保存
+	o.registerRuntime(replayAddress)
+	return o
+}
+
+// recordSession 通过录制器执行一轮登录、查询、签到和WebSocket消息,返回录制文件
+func recordSession(t *testing.T) string {
+	t.Helper()
+	recorder, err := cassette.NewRecorder(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	o := newReplayBot(&capturedLogs{})
+	o.recorder = recorder
+	o.SetTransport(fakeAPI(map[string]string{
+		"/api/v1/auth/generate_token": `{"data":{"token":"secret-token"}}`,
+		"/api/v1/reward":              `{"data":{"totalPoint":"100.50"}}`,
+		"/api/v1/claim_details":       `{"data":{"claimed":false,"dailyPoint":10}}`,
+		"/api/v1/claim_reward":        `{"data":{"claimed":true}}`,
+	}))
+
+	if _, err := o.generateToken(replayAddress, ""); err != nil {
+		t.Fatalf("generateToken: %v", err)
+	}
+	if _, err := o.getUserReward(replayAddress, ""); err != nil {
+		t.Fatalf("getUserReward: %v", err)
+	}
+	if _, err := o.getCheckinDetails(replayAddress, ""); err != nil {
+		t.Fatalf("getCheckinDetails: %v", err)
+	}
+	if _, err := o.claimCheckin(replayAddress, ""); err != nil {
+		t.Fatalf("claimCheckin: %v", err)
+	}
+
+	register, _ := json.Marshal(map[string]string{
+		"msgType":      openledger.MsgTypeRegister,
+		"workerID":     openledger.WorkerID(replayAddress),
+		"ownerAddress": replayAddress,
+	})
+	o.recordFrame(replayAddress, openledger.FrameSent, register)
+	for _, frame := range []string{
+		`{"msgType":"REGISTER","status":true}`,
+		`{"msgType":"HEARTBEAT","message":{"Status":true}}`,
+		`{"msgType":"JOB","UUID":"job-1"}`,
+	} {
+		o.recordFrame(replayAddress, openledger.FrameReceived, []byte(frame))
+	}
+
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return recorder.Path(o.cassetteName(replayAddress))
+}
+
+func TestReplayCassette(t *testing.T) {
+	path := recordSession(t)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := newReplayBot(&capturedLogs{})
+	for _, secret := range []string{"secret-token", strings.ToLower(replayAddress), openledger.WorkerID(replayAddress)} {
+		if strings.Contains(strings.ToLower(string(data)), strings.ToLower(secret)) {
+			t.Errorf("cassette contains %q", secret)
+		}
+	}
+
+	c, err := cassette.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logs := &capturedLogs{}
+	o = newReplayBot(logs)
+	o.SetTransport(c.Transport())
+
+	if _, err := o.generateToken(replayAddress, ""); err != nil {
+		t.Fatalf("generateToken: %v", err)
+	}
+	points, err := o.getUserReward(replayAddress, "")
+	if err != nil {
+		t.Fatalf("getUserReward: %v", err)
+	}
+	if points.StringFixed(2) != "100.50" {
+		t.Errorf("replayed total points = %s, want 100.50", points)
+	}
+	details, err := o.getCheckinDetails(replayAddress, "")
+	if err != nil {
+		t.Fatalf("getCheckinDetails: %v", err)
+	}
+	if details.Data.Claimed || details.Data.DailyPoint.Cmp(openledger.DecimalFromInt(10)) != 0 {
+		t.Errorf("replayed check-in details = %+v", details.Data)
+	}
+	claim, err := o.claimCheckin(replayAddress, "")
+	if err != nil {
+		t.Fatalf("claimCheckin: %v", err)
+	}
+	if !claim.Data.Claimed {
+		t.Error("replayed check-in claim was not claimed")
+	}
+
+	// 每条录制的响应只回放一次
+	if _, err := o.claimCheckin(replayAddress, ""); err == nil {
+		t.Error("second check-in claim was replayed, want no recorded response")
+	}
+
+	acker := &fakeAcker{}
+	frames := c.Frames(openledger.FrameReceived)
+	if len(frames) != 3 {
+		t.Fatalf("cassette has %d received frames, want 3", len(frames))
+	}
+	for _, frame := range frames {
+		if err := o.dispatchMessage(acker, replayAddress, frame); err != nil {
+			t.Fatalf("dispatchMessage(%s): %v", frame, err)
+		}
+	}
+
+	if !logs.contains("WebSocket registered successfully") {
+		t.Error("replayed REGISTER was not handled")
+	}
+	if !logs.contains("Heartbeat acknowledged") {
+		t.Error("replayed heartbeat acknowledgement was not handled")
+	}
+	if len(acker.jobs) != 1 || acker.jobs[0] != "job-1" {
+		t.Errorf("acknowledged jobs = %v, want [job-1]", acker.jobs)
+	}
+}
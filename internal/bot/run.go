@@ -0,0 +1,146 @@
+package bot
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// RunOptions 持续运行模式的选项
+type RunOptions struct {
+	// JSON 日志以每行一个JSON对象写入标准输出,提示信息写入标准错误
+	JSON bool
+	// Watch 账号文件或配置文件变化时自动重新加载
+	Watch bool
+	// ConfigPath 配置文件路径,开启 Watch 时一同监视
+	ConfigPath string
+	// LoadConfig 重新加载时读取配置,由调用方合并命令行参数
+	LoadConfig func() (Config, error)
+}
+
+// Run 创建写入日志文件的bot并持续运行所有账号,直到收到中断信号
+// SIGHUP 和文件变化触发重新加载,启动失败时停止bot并返回错误
+func Run(config Config, options RunOptions) error {
+	// JSON日志与交互式面板互斥
+	if options.JSON {
+		config.TUI = false
+	}
+
+	b, err := newFileBot(config)
+	if err != nil {
+		return err
+	}
+
+	var status io.Writer = os.Stdout
+	if options.JSON {
+		// JSON日志模式下标准输出只保留日志
+		b.SetJSONLogs(os.Stdout)
+		status = os.Stderr
+	} else {
+		clearTerminal()
+		welcome()
+	}
+
+	// 设置信号处理
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	// SIGHUP 和文件变化触发重新加载
+	reloadChan := make(chan os.Signal, 1)
+	signal.Notify(reloadChan, syscall.SIGHUP)
+
+	var watchChan <-chan struct{}
+	if options.Watch {
+		watchChan = WatchFiles([]string{config.accountsFile(), options.ConfigPath}, 2*time.Second)
+	}
+
+	// 启动bot,所有账号结束时 Start 返回nil,继续等待重新加载或中断信号
+	started := make(chan error, 1)
+	go func() { started <- b.Start() }()
+
+	fmt.Fprintln(status, "Bot is running. Press Ctrl+C to exit...")
+	for {
+		select {
+		case <-reloadChan:
+			b.reloadFrom(options.LoadConfig)
+		case <-watchChan:
+			b.reloadFrom(options.LoadConfig)
+		case err := <-started:
+			started = nil
+			if err != nil {
+				// 关闭已启动的面板、控制接口和日志后再退出
+				b.Stop()
+				return err
+			}
+		case <-sigChan:
+			// 收到中断信号
+			fmt.Fprintln(status, "\nShutting down...")
+			b.Stop()
+			return nil
+		}
+	}
+}
+
+// Execute 创建写入日志文件的bot,执行一次性子命令并将结果写入标准输出
+func Execute(command string, config Config, options CommandOptions) error {
+	b, err := newFileBot(config)
+	if err != nil {
+		return err
+	}
+	defer b.Stop()
+	return b.RunCommand(command, options, os.Stdout)
+}
+
+// newFileBot 创建写入日志文件的bot实例
+func newFileBot(config Config) (*OpenLedger, error) {
+	logger, err := NewLogger()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create logger: %w", err)
+	}
+
+	b, err := NewOpenLedger(config, WithLogger(logger))
+	if err != nil {
+		logger.Close()
+		return nil, fmt.Errorf("failed to create bot: %w", err)
+	}
+	return b, nil
+}
+
+// reloadFrom 重新读取配置和账号,读取失败时记录到日志
+func (o *OpenLedger) reloadFrom(load func() (Config, error)) {
+	if load == nil {
+		return
+	}
+	config, err := load()
+	if err != nil {
+		o.log(color.RedString("Reload failed: %v", err))
+		return
+	}
+	// 失败原因已由 Reload 记录到日志
+	o.Reload(config)
+}
+
+// clearTerminal 清理终端
+func clearTerminal() {
+	fmt.Print("\033[H\033[2J")
+}
+
+// welcome 显示欢迎信息
+func welcome() {
+	green := color.New(color.FgGreen, color.Bold)
+	blue := color.New(color.FgBlue, color.Bold)
+	yellow := color.New(color.FgYellow, color.Bold)
+
+	fmt.Println()
+	green.Print("Auto Ping ")
+	blue.Println("Open Ledger - BOT")
+	fmt.Println()
+	green.Print("Rey? ")
+	yellow.Println("<INI WATERMARK>")
+	fmt.Println()
+}
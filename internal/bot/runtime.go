@@ -0,0 +1,399 @@
+package bot
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/gorilla/websocket"
+
+	"openledger/pkg/openledger"
+)
+
+// WebSocket连接状态
+const (
+	wsStatusIdle       = "Idle"
+	wsStatusConnecting = "Connecting"
+	wsStatusConnected  = "Connected"
+	wsStatusRegistered = "Registered"
+	wsStatusClosed     = "Closed"
+	wsStatusPaused     = "Paused"
+	wsStatusSuspended  = "Suspended"
+)
+
+// 签到状态
+const (
+	checkinStatePending   = "-"
+	checkinStateClaimed   = "Claimed"
+	checkinStateUnclaimed = "Unclaimed"
+	checkinStateError     = "Error"
+)
+
+// accountRuntime 单个账号的运行时状态
+type accountRuntime struct {
+	account string
+
+	mu             sync.Mutex
+	state          StateInfo
+	wsStatus       string
+	conn           *openledger.Session
+	heartbeats     heartbeatStats
+	reconciliation reconcileState
+	earnings       Earnings
+	delta          *Decimal
+	checkinState   string
+	tiersClaimed   int
+	tiersTotal     int
+	paused         bool
+	resume         chan struct{}
+	suspended      bool
+	failures       int
+	lastFailure    time.Time
+	client         *openledger.Client
+	clientProxy    string
+	tokens         *openledger.TokenManager
+	done           chan struct{}
+	stopOnce       sync.Once
+
+	// 控制命令通道,由账号的各个goroutine消费
+	reconnect  chan struct{}
+	checkinNow chan struct{}
+	tierNow    chan struct{}
+	renew      chan struct{}
+}
+
+// accountSnapshot 账号状态快照,供面板和状态接口展示
+type accountSnapshot struct {
+	Account        string                  `json:"-"`
+	State          AccountState            `json:"state"`
+	StateSince     time.Time               `json:"stateSince"`
+	StateFor       string                  `json:"stateFor"`
+	StateReason    string                  `json:"stateReason"`
+	WSStatus       string                  `json:"wsStatus"`
+	HeartbeatsAck  int                     `json:"heartbeatsAckedLastHour"`
+	HeartbeatsSent int                     `json:"heartbeatsSentLastHour"`
+	AckRatio       *float64                `json:"ackRatio"`
+	AckLatencyMs   *float64                `json:"lastAckLatencyMs"`
+	AvgLatencyMs   *float64                `json:"avgAckLatencyMs"`
+	MissedAcks     int                     `json:"missedAckStreak"`
+	Reconciliation *ReconcileResult        `json:"reconciliation,omitempty"`
+	ReconcileDays  map[string]ReconcileDay `json:"reconcileDays,omitempty"`
+	TotalPoint     *Decimal                `json:"totalPoint"`
+	Delta          *Decimal                `json:"delta"`
+	Earnings       Earnings                `json:"earnings"`
+	CheckinState   string                  `json:"checkinState"`
+	TiersClaimed   int                     `json:"tiersClaimed"`
+	TiersTotal     int                     `json:"tiersTotal"`
+	Paused         bool                    `json:"paused"`
+	Suspended      bool                    `json:"suspended"`
+	Failures       int                     `json:"failures"`
+}
+
+func newAccountRuntime(account string) *accountRuntime {
+	return &accountRuntime{
+		account:      account,
+		state:        StateInfo{State: StateInit, Since: time.Now(), Reason: "account registered"},
+		wsStatus:     wsStatusIdle,
+		checkinState: checkinStatePending,
+		resume:       make(chan struct{}),
+		done:         make(chan struct{}),
+		reconnect:    make(chan struct{}, 1),
+		checkinNow:   make(chan struct{}, 1),
+		tierNow:      make(chan struct{}, 1),
+		renew:        make(chan struct{}, 1),
+	}
+}
+
+// registerRuntime 为账号创建并登记新的运行时状态
+func (o *OpenLedger) registerRuntime(account string) *accountRuntime {
+	o.runtimeMutex.Lock()
+	defer o.runtimeMutex.Unlock()
+
+	rt := newAccountRuntime(account)
+	key := accountKey(account)
+	if _, ok := o.runtimes[key]; !ok {
+		o.runtimeOrder = append(o.runtimeOrder, account)
+	}
+	o.runtimes[key] = rt
+	return rt
+}
+
+// unregisterRuntime 移除账号的运行时状态
+func (o *OpenLedger) unregisterRuntime(rt *accountRuntime) {
+	o.runtimeMutex.Lock()
+	defer o.runtimeMutex.Unlock()
+
+	key := accountKey(rt.account)
+	if o.runtimes[key] != rt {
+		return
+	}
+	delete(o.runtimes, key)
+	for i, account := range o.runtimeOrder {
+		if accountKey(account) == key {
+			o.runtimeOrder = append(o.runtimeOrder[:i], o.runtimeOrder[i+1:]...)
+			break
+		}
+	}
+}
+
+// runtime 获取账号运行时状态,账号未登记时返回一个独立的空状态
+func (o *OpenLedger) runtime(account string) *accountRuntime {
+	o.runtimeMutex.Lock()
+	defer o.runtimeMutex.Unlock()
+
+	if rt, ok := o.runtimes[accountKey(account)]; ok {
+		return rt
+	}
+	return newAccountRuntime(account)
+}
+
+// accountList 按启动顺序返回所有登记的账号
+func (o *OpenLedger) accountList() []string {
+	o.runtimeMutex.Lock()
+	defer o.runtimeMutex.Unlock()
+
+	return append([]string(nil), o.runtimeOrder...)
+}
+
+// snapshots 按启动顺序返回所有账号的状态快照
+func (o *OpenLedger) snapshots() []accountSnapshot {
+	o.runtimeMutex.Lock()
+	runtimes := make([]*accountRuntime, 0, len(o.runtimeOrder))
+	for _, account := range o.runtimeOrder {
+		runtimes = append(runtimes, o.runtimes[accountKey(account)])
+	}
+	o.runtimeMutex.Unlock()
+
+	result := make([]accountSnapshot, 0, len(runtimes))
+	for _, rt := range runtimes {
+		result = append(result, rt.snapshot())
+	}
+	return result
+}
+
+func (rt *accountRuntime) snapshot() accountSnapshot {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	rt.heartbeats.prune(time.Now())
+	reconciliation, days := rt.reconcileSnapshotLocked()
+	return accountSnapshot{
+		Account:        rt.account,
+		State:          rt.state.State,
+		StateSince:     rt.state.Since,
+		StateFor:       rt.state.Duration().Round(time.Second).String(),
+		StateReason:    rt.state.Reason,
+		WSStatus:       rt.wsStatus,
+		HeartbeatsAck:  len(rt.heartbeats.acked),
+		HeartbeatsSent: len(rt.heartbeats.sent),
+		AckRatio:       rt.heartbeats.ackRatio(),
+		AckLatencyMs:   milliseconds(rt.heartbeats.lastLatency),
+		AvgLatencyMs:   milliseconds(rt.heartbeats.avgLatency),
+		MissedAcks:     rt.heartbeats.missedStreak,
+		Reconciliation: reconciliation,
+		ReconcileDays:  days,
+		TotalPoint:     rt.earnings.Total(),
+		Delta:          rt.delta,
+		Earnings:       rt.earnings,
+		CheckinState:   rt.checkinState,
+		TiersClaimed:   rt.tiersClaimed,
+		TiersTotal:     rt.tiersTotal,
+		Paused:         rt.paused,
+		Suspended:      rt.suspended,
+		Failures:       rt.failures,
+	}
+}
+
+func (rt *accountRuntime) setWSStatus(status string) {
+	rt.mu.Lock()
+	rt.wsStatus = status
+	rt.mu.Unlock()
+}
+
+func (rt *accountRuntime) setConn(conn *openledger.Session) {
+	rt.mu.Lock()
+	rt.conn = conn
+	rt.mu.Unlock()
+}
+
+// setEarnings 记录一次积分查询结果,保留签到和等级积分,返回合并后的明细和与上次查询相比的变化
+func (rt *accountRuntime) setEarnings(sample Earnings) (Earnings, *Decimal) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	previous := rt.earnings.Total()
+	rt.earnings.Base = sample.Base
+	rt.earnings.TodayHeartbeats = sample.TodayHeartbeats
+	rt.earnings.Heartbeats = sample.Heartbeats
+
+	rt.delta = nil
+	if current := rt.earnings.Total(); current != nil && previous != nil {
+		rt.delta = known(current.Sub(*previous))
+	}
+	return rt.earnings, rt.delta
+}
+
+// setCheckinPoints 记录今日签到积分
+func (rt *accountRuntime) setCheckinPoints(points Decimal) {
+	rt.mu.Lock()
+	rt.earnings.Checkin = known(points)
+	rt.mu.Unlock()
+}
+
+// setTierPoints 记录已领取的等级奖励合计
+func (rt *accountRuntime) setTierPoints(points Decimal) {
+	rt.mu.Lock()
+	rt.earnings.Tier = known(points)
+	rt.mu.Unlock()
+}
+
+func (rt *accountRuntime) setCheckinState(state string) {
+	rt.mu.Lock()
+	rt.checkinState = state
+	rt.mu.Unlock()
+}
+
+func (rt *accountRuntime) setTierProgress(claimed, total int) {
+	rt.mu.Lock()
+	rt.tiersClaimed = claimed
+	rt.tiersTotal = total
+	rt.mu.Unlock()
+}
+
+// currentToken 获取令牌管理器缓存的访问令牌,还没有令牌时为空
+func (rt *accountRuntime) currentToken() string {
+	rt.mu.Lock()
+	tokens := rt.tokens
+	rt.mu.Unlock()
+	if tokens == nil {
+		return ""
+	}
+	return tokens.Current()
+}
+
+func (rt *accountRuntime) isPaused() bool {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	return rt.paused
+}
+
+// setPaused 暂停或恢复账号,暂停时会断开当前WebSocket连接,恢复时清除挂起状态和失败次数
+// 发送关闭帧可能阻塞到写超时,在释放锁之后进行,不影响快照读取
+func (rt *accountRuntime) setPaused(paused bool) {
+	rt.mu.Lock()
+	if !paused {
+		rt.suspended = false
+		rt.failures = 0
+	}
+	if rt.paused == paused {
+		rt.mu.Unlock()
+		return
+	}
+	rt.paused = paused
+	if !paused {
+		close(rt.resume)
+		rt.resume = make(chan struct{})
+		rt.mu.Unlock()
+		return
+	}
+	conn := rt.conn
+	rt.mu.Unlock()
+
+	if conn != nil {
+		conn.CloseWithReason(websocket.CloseNormalClosure, "worker paused")
+	}
+}
+
+// stop 停止账号的所有任务并断开WebSocket连接
+func (rt *accountRuntime) stop() {
+	rt.stopOnce.Do(func() {
+		close(rt.done)
+
+		rt.mu.Lock()
+		conn := rt.conn
+		rt.mu.Unlock()
+		if conn != nil {
+			conn.CloseWithReason(websocket.CloseNormalClosure, "worker shutting down")
+		}
+	})
+}
+
+func (rt *accountRuntime) stopped() bool {
+	select {
+	case <-rt.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// active 判断账号的任务是否应继续运行
+func (o *OpenLedger) active(rt *accountRuntime) bool {
+	return o.running.Load() && !rt.stopped()
+}
+
+// waitIfPaused 账号暂停时阻塞,直到恢复或账号停止
+func (o *OpenLedger) waitIfPaused(rt *accountRuntime) {
+	for o.active(rt) {
+		rt.mu.Lock()
+		paused, resume := rt.paused, rt.resume
+		rt.mu.Unlock()
+		if !paused {
+			return
+		}
+
+		select {
+		case <-resume:
+		case <-rt.done:
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// togglePause 切换账号的暂停状态
+func (o *OpenLedger) togglePause(account string) {
+	rt := o.runtime(account)
+	paused := !rt.isPaused()
+	suspended := rt.isSuspended()
+	rt.setPaused(paused)
+
+	// 恢复挂起的账号,没有令牌时重新认证
+	if !paused && suspended {
+		if rt.currentToken() == "" {
+			o.transition(rt, StateAuthenticating, "resumed")
+		} else {
+			o.transition(rt, StateDegraded, "resumed")
+		}
+	}
+
+	if paused {
+		o.log(fmt.Sprintf("%s Account: %s - Paused",
+			color.YellowString("!"),
+			color.WhiteString(o.displayName(account))))
+	} else {
+		o.log(fmt.Sprintf("%s Account: %s - Resumed",
+			color.GreenString("✓"),
+			color.WhiteString(o.displayName(account))))
+	}
+}
+
+// trigger 非阻塞地发送一次控制信号,已有未处理的信号时忽略
+func trigger(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// sleep 等待指定时长,收到唤醒信号或账号停止时提前返回
+func (rt *accountRuntime) sleep(d time.Duration, wake <-chan struct{}) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-wake:
+	case <-rt.done:
+	}
+}
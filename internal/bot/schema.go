@@ -0,0 +1,229 @@
+package bot
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+)
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// schemaTracker 记录每个接口见过的响应字段,发现结构变化时告警,每种问题每次运行只报告一次
+type schemaTracker struct {
+	mu       sync.Mutex
+	seen     map[string]map[string]bool
+	reported map[string]bool
+}
+
+func newSchemaTracker() *schemaTracker {
+	return &schemaTracker{
+		seen:     make(map[string]map[string]bool),
+		reported: make(map[string]bool),
+	}
+}
+
+// once 判断问题是否第一次出现
+func (s *schemaTracker) once(key string) bool {
+	if s.reported[key] {
+		return false
+	}
+	s.reported[key] = true
+	return true
+}
+
+// checkResponse 检查响应结构是否与 pkg/openledger/types.go 中的定义一致
+func (o *OpenLedger) checkResponse(endpoint string, status int, body []byte, v interface{}) {
+	// 错误响应的结构本来就不同
+	if status < 200 || status > 299 {
+		return
+	}
+
+	var raw interface{}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return
+	}
+	o.checkSchema(endpoint, reflect.TypeOf(v), raw)
+}
+
+// checkSchema 比较响应中的字段和结构体定义的字段
+func (o *OpenLedger) checkSchema(endpoint string, t reflect.Type, raw interface{}) {
+	expected := make(map[string]bool)
+	typePaths(t, "", expected)
+
+	// 与 encoding/json 一样不区分大小写匹配字段名,只改了大小写的字段按定义的名称记录
+	names := make(map[string]string, len(expected))
+	for path := range expected {
+		names[strings.ToLower(path)] = path
+	}
+
+	actual := make(map[string]bool)
+	opaque := make(map[string]bool)
+	valuePaths(raw, "", names, actual, opaque)
+
+	var missing, unknown, added []string
+	for path := range expected {
+		if !actual[path] && !underOpaque(path, opaque) {
+			missing = append(missing, path)
+		}
+	}
+	for path := range actual {
+		if !expected[path] && !parentIn(path, actual, expected) {
+			unknown = append(unknown, path)
+		}
+	}
+	missing = topLevel(missing)
+	sort.Strings(unknown)
+
+	s := o.schemas
+	s.mu.Lock()
+	seen, baseline := s.seen[endpoint]
+	if !baseline {
+		seen = make(map[string]bool)
+		s.seen[endpoint] = seen
+	}
+	for path := range actual {
+		if !seen[path] {
+			seen[path] = true
+			if baseline && !expected[path] {
+				added = append(added, path)
+			}
+		}
+	}
+	sort.Strings(added)
+	added = topLevel(added)
+
+	strict := o.settings().StrictDecode
+	reportMissing := len(missing) > 0 && s.once(endpoint+" missing "+strings.Join(missing, ","))
+	reportUnknown := strict && len(unknown) > 0 && s.once(endpoint+" unknown")
+	reportAdded := len(added) > 0 && s.once(endpoint+" added "+strings.Join(added, ","))
+	s.mu.Unlock()
+
+	if reportMissing {
+		o.log(color.YellowString("! Schema: %s response is missing expected field(s) %s, values will read as zero",
+			endpoint, strings.Join(missing, ", ")))
+	}
+	if reportAdded {
+		o.log(color.YellowString("! Schema: %s response shape changed, new field(s) %s",
+			endpoint, strings.Join(added, ", ")))
+	}
+	if reportUnknown {
+		o.log(color.MagentaString("~ Schema: %s response has unknown field(s) %s",
+			endpoint, strings.Join(topLevel(unknown), ", ")))
+	}
+}
+
+// typePaths 收集结构体定义的JSON字段路径,数组元素用 [] 表示
+func typePaths(t reflect.Type, prefix string, paths map[string]bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Implements(unmarshalerType) || reflect.PointerTo(t).Implements(unmarshalerType) {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name := field.Name
+			if tag := field.Tag.Get("json"); tag != "" {
+				if tag == "-" {
+					continue
+				}
+				if n, _, _ := strings.Cut(tag, ","); n != "" {
+					name = n
+				}
+			}
+			path := joinPath(prefix, name)
+			paths[path] = true
+			typePaths(field.Type, path, paths)
+		}
+	case reflect.Slice, reflect.Array:
+		typePaths(t.Elem(), prefix+"[]", paths)
+	}
+}
+
+// valuePaths 收集响应中出现的字段路径,空数组和null下的字段记为无法判断
+// names 为小写路径到定义路径的映射,大小写不同的已知字段记为定义的路径
+func valuePaths(v interface{}, prefix string, names map[string]string, paths, opaque map[string]bool) {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for key, child := range value {
+			path := joinPath(prefix, key)
+			if name, ok := names[strings.ToLower(path)]; ok {
+				path = name
+			}
+			paths[path] = true
+			valuePaths(child, path, names, paths, opaque)
+		}
+	case []interface{}:
+		if len(value) == 0 {
+			opaque[prefix+"[]"] = true
+		}
+		for _, child := range value {
+			valuePaths(child, prefix+"[]", names, paths, opaque)
+		}
+	case nil:
+		if prefix != "" {
+			opaque[prefix] = true
+		}
+	}
+}
+
+func joinPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// underOpaque 字段位于空数组或null之下时无法判断是否缺失
+func underOpaque(path string, opaque map[string]bool) bool {
+	for prefix := range opaque {
+		if strings.HasPrefix(path, prefix+".") || strings.HasPrefix(path, prefix+"[]") {
+			return true
+		}
+	}
+	return false
+}
+
+// parentIn 上级字段本身就是未知字段时不重复报告
+func parentIn(path string, actual, expected map[string]bool) bool {
+	for parent := parentPath(path); parent != ""; parent = parentPath(parent) {
+		if actual[parent] && !expected[parent] {
+			return true
+		}
+	}
+	return false
+}
+
+func parentPath(path string) string {
+	i := strings.LastIndex(path, ".")
+	if i < 0 {
+		return ""
+	}
+	return strings.TrimSuffix(path[:i], "[]")
+}
+
+// topLevel 去掉已被上级路径包含的路径
+func topLevel(paths []string) []string {
+	sort.Strings(paths)
+	var result []string
+	for _, path := range paths {
+		if len(result) > 0 {
+			last := result[len(result)-1]
+			if strings.HasPrefix(path, last+".") || strings.HasPrefix(path, last+"[]") {
+				continue
+			}
+		}
+		result = append(result, path)
+	}
+	return result
+}
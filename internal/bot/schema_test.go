@@ -0,0 +1,26 @@
+package bot
+
+import (
+	"net/http"
+	"testing"
+
+	"openledger/pkg/openledger"
+)
+
+func TestCheckResponseIgnoresFieldCase(t *testing.T) {
+	logs := &capturedLogs{}
+	o := newReplayBot(logs)
+	o.config.StrictDecode = true
+
+	body := []byte(`{"Data":{"TotalPoint":"1.5"}}`)
+	o.checkResponse("reward", http.StatusOK, body, &openledger.UserRewardResponse{})
+	if len(logs.lines) != 0 {
+		t.Errorf("field renamed only by case was reported: %q", logs.lines)
+	}
+
+	body = []byte(`{"data":{"points":"1.5"}}`)
+	o.checkResponse("reward", http.StatusOK, body, &openledger.UserRewardResponse{})
+	if !logs.contains("missing expected field(s) data.totalPoint") || !logs.contains("unknown field(s) data.points") {
+		t.Errorf("renamed field was not reported: %q", logs.lines)
+	}
+}
@@ -0,0 +1,137 @@
+package bot
+
+import (
+	"fmt"
+	"time"
+)
+
+// AccountState 账号的健康状态
+type AccountState string
+
+// 账号状态
+const (
+	// StateInit 账号已登记,尚未开始认证
+	StateInit AccountState = "Init"
+	// StateAuthenticating 正在生成初始访问令牌
+	StateAuthenticating AccountState = "Authenticating"
+	// StateConnecting 已取得令牌,正在建立WebSocket连接
+	StateConnecting AccountState = "Connecting"
+	// StateRegistered WebSocket注册成功,等待心跳确认
+	StateRegistered AccountState = "Registered"
+	// StateHealthy 心跳被确认,未启用WebSocket时为积分查询成功
+	StateHealthy AccountState = "Healthy"
+	// StateDegraded 连接断开、令牌更新失败或接口出错,等待恢复
+	StateDegraded AccountState = "Degraded"
+	// StateSuspended 连续失败后被挂起,恢复前不再运行
+	StateSuspended AccountState = "Suspended"
+	// StateStopped 账号已停止
+	StateStopped AccountState = "Stopped"
+)
+
+// stateTransitions 每个状态允许切换到的状态
+var stateTransitions = map[AccountState][]AccountState{
+	StateInit:           {StateAuthenticating, StateStopped},
+	StateAuthenticating: {StateConnecting, StateHealthy, StateDegraded, StateSuspended, StateStopped},
+	StateConnecting:     {StateRegistered, StateHealthy, StateDegraded, StateSuspended, StateStopped},
+	StateRegistered:     {StateHealthy, StateDegraded, StateSuspended, StateStopped},
+	StateHealthy:        {StateDegraded, StateSuspended, StateStopped},
+	StateDegraded:       {StateAuthenticating, StateConnecting, StateRegistered, StateHealthy, StateSuspended, StateStopped},
+	StateSuspended:      {StateAuthenticating, StateDegraded, StateStopped},
+	StateStopped:        {},
+}
+
+// canTransition 判断是否允许从 from 切换到 to
+func canTransition(from, to AccountState) bool {
+	for _, state := range stateTransitions[from] {
+		if state == to {
+			return true
+		}
+	}
+	return false
+}
+
+// StateInfo 账号当前状态
+type StateInfo struct {
+	State  AccountState `json:"state"`
+	Since  time.Time    `json:"since"`
+	Reason string       `json:"reason"`
+}
+
+// Duration 处于当前状态的时长
+func (s StateInfo) Duration() time.Duration {
+	return time.Since(s.Since)
+}
+
+// setState 切换账号状态,不允许的切换会被忽略,返回切换前的状态和是否切换
+func (rt *accountRuntime) setState(state AccountState, reason string, now time.Time) (AccountState, bool) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	from := rt.state.State
+	if !canTransition(from, state) {
+		return from, false
+	}
+	rt.state = StateInfo{State: state, Since: now, Reason: reason}
+	return from, true
+}
+
+func (rt *accountRuntime) stateInfo() StateInfo {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	return rt.state
+}
+
+// transition 切换账号状态并发布 StateChanged 事件
+func (o *OpenLedger) transition(rt *accountRuntime, state AccountState, reason string) {
+	if from, ok := rt.setState(state, reason, time.Now()); ok {
+		o.emit(rt.account, &StateChanged{From: from, To: state, Reason: reason})
+	}
+}
+
+// AccountState 返回账号的当前状态,账号未运行时返回false
+func (o *OpenLedger) AccountState(account string) (StateInfo, bool) {
+	o.runtimeMutex.Lock()
+	rt, ok := o.runtimes[accountKey(account)]
+	o.runtimeMutex.Unlock()
+	if !ok {
+		return StateInfo{}, false
+	}
+	return rt.stateInfo(), true
+}
+
+// trackState 根据令牌、WebSocket和接口事件切换账号状态
+func (o *OpenLedger) trackState(e Event) {
+	account := e.base().Account
+	rt := o.runtime(account)
+	websocket := o.accountConfig(account).Tasks.WebSocket
+
+	switch e := e.(type) {
+	case *TokenGenerated:
+		if e.Renewed {
+			return
+		}
+		if websocket {
+			o.transition(rt, StateConnecting, "token generated")
+		} else {
+			o.transition(rt, StateHealthy, "token generated")
+		}
+	case *AuthFailed:
+		if e.Renewal {
+			o.transition(rt, StateDegraded, "token renewal failed")
+		}
+	case *WSRegistered:
+		o.transition(rt, StateRegistered, "websocket registered")
+	case *WSClosed:
+		o.transition(rt, StateDegraded, "websocket closed")
+	case *HeartbeatAcked:
+		o.transition(rt, StateHealthy, "heartbeat acknowledged")
+	case *HeartbeatsMissed:
+		o.transition(rt, StateDegraded, fmt.Sprintf("%d heartbeats not acknowledged", e.Streak))
+	case *EarningsSampled:
+		if !websocket {
+			o.transition(rt, StateHealthy, "earnings updated")
+		}
+	case *AccountSuspended:
+		o.transition(rt, StateSuspended, fmt.Sprintf("%d consecutive failures", e.Failures))
+	}
+}
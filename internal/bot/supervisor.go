@@ -0,0 +1,120 @@
+package bot
+
+import (
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+// 任务重启参数
+const (
+	restartBaseDelay = 5 * time.Second
+	restartMaxDelay  = 5 * time.Minute
+	// suspendAfter 连续失败多少次后挂起账号
+	suspendAfter = 5
+	// failureResetAfter 距上次失败超过该时长后重新计算连续失败次数
+	failureResetAfter = 10 * time.Minute
+)
+
+// restartDelay 第n次连续失败后的重启间隔,每次加倍
+func restartDelay(failures int) time.Duration {
+	delay := restartBaseDelay
+	for i := 1; i < failures && delay < restartMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > restartMaxDelay {
+		delay = restartMaxDelay
+	}
+	return delay
+}
+
+// recovered 将recover()的结果转换为错误并记录调用栈,没有panic时返回nil
+// 必须在defer的函数中以 o.recovered(account, task, recover()) 的形式调用
+func (o *OpenLedger) recovered(account, task string, value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	o.emit(account, &TaskPanicked{Task: task, Value: fmt.Sprint(value), Stack: string(debug.Stack())})
+	return fmt.Errorf("%s task panicked: %v", task, value)
+}
+
+// runTask 运行一次任务,panic时返回错误
+func (o *OpenLedger) runTask(account, task string, run func() error) (err error) {
+	defer func() {
+		if perr := o.recovered(account, task, recover()); perr != nil {
+			err = perr
+		}
+	}()
+	return run()
+}
+
+// supervise 运行账号的一个任务,任务panic或意外退出时按退避间隔重启,账号停止后返回
+func (o *OpenLedger) supervise(rt *accountRuntime, task string, run func()) {
+	for o.active(rt) {
+		err := o.runTask(rt.account, task, func() error {
+			run()
+			return nil
+		})
+		if !o.active(rt) {
+			return
+		}
+		if err == nil {
+			err = fmt.Errorf("%s task exited unexpectedly", task)
+		}
+		o.restartAfter(rt, task, err)
+	}
+}
+
+// restartAfter 记录一次失败并等待重启,连续失败达到上限时挂起账号,直到恢复或停止
+func (o *OpenLedger) restartAfter(rt *accountRuntime, task string, err error) {
+	failures := rt.recordFailure(time.Now())
+	if failures >= suspendAfter {
+		o.suspendAccount(rt, failures, err)
+		o.waitIfPaused(rt)
+		return
+	}
+
+	delay := restartDelay(failures)
+	o.emit(rt.account, &TaskRestarting{Task: task, Attempt: failures, Delay: delay, Err: err})
+	rt.sleep(delay, nil)
+}
+
+// suspendAccount 挂起账号并发布 AccountSuspended 事件,已挂起时忽略
+func (o *OpenLedger) suspendAccount(rt *accountRuntime, failures int, err error) {
+	if rt.suspend() {
+		o.emit(rt.account, &AccountSuspended{Failures: failures, Err: err})
+	}
+}
+
+// recordFailure 记录一次失败,返回连续失败次数
+func (rt *accountRuntime) recordFailure(now time.Time) int {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	if now.Sub(rt.lastFailure) > failureResetAfter {
+		rt.failures = 0
+	}
+	rt.failures++
+	rt.lastFailure = now
+	return rt.failures
+}
+
+// suspend 挂起账号,账号保持暂停直到恢复,已挂起时返回false
+func (rt *accountRuntime) suspend() bool {
+	rt.mu.Lock()
+	if rt.suspended {
+		rt.mu.Unlock()
+		return false
+	}
+	rt.suspended = true
+	rt.mu.Unlock()
+
+	rt.setPaused(true)
+	return true
+}
+
+func (rt *accountRuntime) isSuspended() bool {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	return rt.suspended
+}
@@ -0,0 +1,20 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !windows
+
+package bot
+
+import (
+	"errors"
+	"os"
+)
+
+var errTerminalUnsupported = errors.New("terminal control is not supported on this platform")
+
+// enterCbreakMode 当前平台不支持终端控制
+func enterCbreakMode(in, out *os.File) (func(), error) {
+	return nil, errTerminalUnsupported
+}
+
+// terminalSize 当前平台不支持终端控制
+func terminalSize(out *os.File) (int, int, error) {
+	return 0, 0, errTerminalUnsupported
+}
@@ -0,0 +1,46 @@
+package bot
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// enterCbreakMode 关闭控制台回显和行缓冲并启用ANSI序列,返回恢复函数
+func enterCbreakMode(in, out *os.File) (func(), error) {
+	inHandle := windows.Handle(in.Fd())
+	outHandle := windows.Handle(out.Fd())
+
+	var inMode, outMode uint32
+	if err := windows.GetConsoleMode(inHandle, &inMode); err != nil {
+		return nil, err
+	}
+	if err := windows.GetConsoleMode(outHandle, &outMode); err != nil {
+		return nil, err
+	}
+
+	newInMode := inMode&^(windows.ENABLE_LINE_INPUT|windows.ENABLE_ECHO_INPUT) | windows.ENABLE_VIRTUAL_TERMINAL_INPUT
+	if err := windows.SetConsoleMode(inHandle, newInMode); err != nil {
+		return nil, err
+	}
+	if err := windows.SetConsoleMode(outHandle, outMode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING); err != nil {
+		windows.SetConsoleMode(inHandle, inMode)
+		return nil, err
+	}
+
+	return func() {
+		windows.SetConsoleMode(inHandle, inMode)
+		windows.SetConsoleMode(outHandle, outMode)
+	}, nil
+}
+
+// terminalSize 获取控制台宽高
+func terminalSize(out *os.File) (int, int, error) {
+	var info windows.ConsoleScreenBufferInfo
+	if err := windows.GetConsoleScreenBufferInfo(windows.Handle(out.Fd()), &info); err != nil {
+		return 0, 0, err
+	}
+	width := int(info.Window.Right-info.Window.Left) + 1
+	height := int(info.Window.Bottom-info.Window.Top) + 1
+	return width, height, nil
+}
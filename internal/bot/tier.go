@@ -1,163 +1,111 @@
 package bot
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"net/http"
 	"time"
 
 	"github.com/fatih/color"
-)
 
-type claimTierRequest struct {
-	TierID int `json:"tierId"`
-}
+	"openledger/pkg/openledger"
+)
 
 // getTierDetails 获取等级详情
-func (o *OpenLedger) getTierDetails(account, token, proxy string) (*TierDetailsResponse, error) {
-	url := "https://rewardstn.openledger.xyz/api/v1/tier_details"
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	if proxy != "" {
-		if transport, err := o.getProxyClient(proxy); err == nil {
-			client.Transport = transport
-		}
-	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == 401 {
-		newToken, err := o.renewToken(account, proxy)
-		if err != nil {
-			return nil, fmt.Errorf("token renewal failed: %w", err)
-		}
-		return o.getTierDetails(account, newToken, proxy)
-	}
-
-	var result TierDetailsResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	return &result, nil
+func (o *OpenLedger) getTierDetails(account, proxy string) (*openledger.TierDetailsResponse, error) {
+	var result *openledger.TierDetailsResponse
+	err := o.callAPI(account, proxy, func(client *openledger.Client, token string) (err error) {
+		result, err = client.TierDetails(context.Background(), token)
+		return err
+	})
+	return result, err
 }
 
-// claimTier 领取等级奖励
-func (o *OpenLedger) claimTier(account, token, proxy string, tierID int) (*ClaimTierResponse, error) {
-	url := "https://rewardstn.openledger.xyz/api/v1/claim_tier"
-
-	data := claimTierRequest{
-		TierID: tierID,
-	}
-
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+// claimTier 领取等级奖励,暂不可领取时返回nil
+func (o *OpenLedger) claimTier(account, proxy string, tierID int) (*openledger.ClaimTierResponse, error) {
+	if o.dryRun() {
+		o.logDryRun(account, fmt.Sprintf("claim tier %d", tierID))
+		return nil, errDryRun
 	}
 
-	req, err := http.NewRequest("PUT", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	if proxy != "" {
-		if transport, err := o.getProxyClient(proxy); err == nil {
-			client.Transport = transport
-		}
-	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == 401 {
-		newToken, err := o.renewToken(account, proxy)
-		if err != nil {
-			return nil, fmt.Errorf("token renewal failed: %w", err)
-		}
-		return o.claimTier(account, newToken, proxy, tierID)
-	} else if resp.StatusCode == 420 {
+	var result *openledger.ClaimTierResponse
+	err := o.callAPI(account, proxy, func(client *openledger.Client, token string) (err error) {
+		result, err = client.ClaimTier(context.Background(), token, tierID)
+		return err
+	})
+	if errors.Is(err, openledger.ErrNotEligible) {
 		return nil, nil
 	}
-
-	var result ClaimTierResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	return &result, nil
+	return result, err
 }
 
 // processClaimTier 处理等级奖励领取
-func (o *OpenLedger) processClaimTier(account, token, proxy string, errChan chan<- error) {
-	for o.running {
-		tiers, err := o.getTierDetails(account, token, proxy)
+func (o *OpenLedger) processClaimTier(account, proxy string, errChan chan<- error) {
+	rt := o.runtime(account)
+	for o.active(rt) {
+		o.waitIfPaused(rt)
+
+		tiers, err := o.getTierDetails(account, proxy)
 		if err != nil {
 			errChan <- fmt.Errorf("get tier details failed: %w", err)
-			time.Sleep(time.Minute)
+			rt.sleep(time.Minute, rt.tierNow)
 			continue
 		}
 
 		if tiers == nil || len(tiers.Data.TierDetails) == 0 {
 			o.log(fmt.Sprintf("%s Account: %s - Tier: GET Data Failed",
 				color.CyanString("["),
-				color.WhiteString(o.hideAccount(account))))
-			time.Sleep(24 * time.Hour)
+				color.WhiteString(o.displayName(account))))
+			rt.sleep(time.Duration(o.settings().TierInterval), rt.tierNow)
 			continue
 		}
 
 		completed := true
+		claimed := 0
+		points := claimedTierPoints(tiers.Data.TierDetails)
 		for _, tier := range tiers.Data.TierDetails {
-			if !tier.ClaimStatus {
+			if tier.ClaimStatus {
+				claimed++
+			} else {
 				completed = false
-				claim, err := o.claimTier(account, token, proxy, tier.ID)
+				claim, err := o.claimTier(account, proxy, tier.ID)
+				if errors.Is(err, errDryRun) {
+					o.log(fmt.Sprintf("%s Account: %s - Tier: %s - Status: Would Claim - Reward: %s PTS",
+						color.CyanString("["),
+						color.WhiteString(o.displayName(account)),
+						tier.Name,
+						tier.Value.StringFixed(2)))
+					continue
+				}
 				if err != nil {
 					errChan <- fmt.Errorf("claim tier failed: %w", err)
 					continue
 				}
 
 				if claim != nil && claim.Status == "SUCCESS" {
-					o.log(fmt.Sprintf("%s Account: %s - Tier: %s - Status: Is Claimed - Reward: %.2f PTS",
-						color.CyanString("["),
-						color.WhiteString(o.hideAccount(account)),
-						tier.Name,
-						tier.Value))
+					claimed++
+					points = points.Add(tier.Value)
+					o.emit(account, &TierClaimed{TierID: tier.ID, Tier: tier.Name, Points: tier.Value})
 				} else {
 					o.log(fmt.Sprintf("%s Account: %s - Tier: %s - Status: Not Eligible to Claim",
 						color.CyanString("["),
-						color.WhiteString(o.hideAccount(account)),
+						color.WhiteString(o.displayName(account)),
 						tier.Name))
 				}
 				time.Sleep(time.Second)
 			}
 		}
 
+		rt.setTierProgress(claimed, len(tiers.Data.TierDetails))
+		rt.setTierPoints(points)
+
 		if completed {
 			o.log(fmt.Sprintf("%s Account: %s - Tier: All Available Tier Is Completed",
 				color.CyanString("["),
-				color.WhiteString(o.hideAccount(account))))
+				color.WhiteString(o.displayName(account))))
 		}
 
-		// 每24小时检查一次
-		time.Sleep(24 * time.Hour)
+		// 按配置的间隔检查(默认24小时),收到控制命令时立即执行
+		rt.sleep(time.Duration(o.settings().TierInterval), rt.tierNow)
 	}
 }
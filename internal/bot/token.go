@@ -1,129 +1,61 @@
 package bot
 
 import (
-	"bytes"
-	"encoding/json"
-	"fmt"
-	"net/http"
+	"context"
 	"time"
 
-	"github.com/fatih/color"
+	"openledger/pkg/openledger"
 )
 
-type tokenRequest struct {
-	Address string `json:"address"`
-}
-
-// generateToken 生成访问令牌
+// generateToken 通过账号的令牌管理器生成新的访问令牌,失败时重试
 func (o *OpenLedger) generateToken(account string, proxy string) (string, error) {
-	maxRetries := 5
-	for attempt := 0; attempt < maxRetries; attempt++ {
-		url := "https://apitn.openledger.xyz/api/v1/auth/generate_token"
-		data := tokenRequest{
-			Address: account,
-		}
-
-		jsonData, err := json.Marshal(data)
-		if err != nil {
-			return "", fmt.Errorf("failed to marshal token request: %w", err)
-		}
-
-		// 创建请求
-		req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-		if err != nil {
-			return "", fmt.Errorf("failed to create request: %w", err)
-		}
-
-		// 设置请求头
-		req.Header.Set("Accept", "application/json, text/plain, */*")
-		req.Header.Set("Accept-Language", "id-ID,id;q=0.9,en-US;q=0.8,en;q=0.7")
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Origin", "https://testnet.openledger.xyz")
-		req.Header.Set("Referer", "https://testnet.openledger.xyz/")
-		req.Header.Set("User-Agent", o.generateUserAgent())
-
-		// 创建客户端
-		client := &http.Client{
-			Timeout: 30 * time.Second,
-		}
-
-		// 如果有代理,设置代理
-		if proxy != "" {
-			proxyURL, err := o.getProxyClient(proxy)
-			if err != nil {
-				return "", fmt.Errorf("failed to set proxy: %w", err)
-			}
-			client.Transport = proxyURL
-		}
-
-		// 发送请求
-		resp, err := client.Do(req)
-		if err != nil {
-			if attempt < maxRetries-1 {
-				o.log(fmt.Sprintf("%s Account %s - Retrying token generation (attempt %d/%d)...",
-					color.YellowString("!"),
-					color.WhiteString(o.hideAccount(account)),
-					attempt+1,
-					maxRetries))
-				time.Sleep(time.Duration(attempt+1) * 2 * time.Second)
-				continue
-			}
-			return "", fmt.Errorf("request failed: %w", err)
-		}
-		defer resp.Body.Close()
-
-		// 解析响应
-		var tokenResp TokenResponse
-		if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
-			if attempt < maxRetries-1 {
-				o.log(fmt.Sprintf("%s Account %s - Invalid token response, retrying (attempt %d/%d)...",
-					color.YellowString("!"),
-					color.WhiteString(o.hideAccount(account)),
-					attempt+1,
-					maxRetries))
-				time.Sleep(time.Duration(attempt+1) * 2 * time.Second)
-				continue
-			}
-			return "", fmt.Errorf("failed to decode response: %w", err)
-		}
-
-		if tokenResp.Data.Token == "" {
-			if attempt < maxRetries-1 {
-				o.log(fmt.Sprintf("%s Account %s - Empty token received, retrying (attempt %d/%d)...",
-					color.YellowString("!"),
-					color.WhiteString(o.hideAccount(account)),
-					attempt+1,
-					maxRetries))
-				time.Sleep(time.Duration(attempt+1) * 2 * time.Second)
-				continue
-			}
-			return "", fmt.Errorf("received empty token")
-		}
+	_, tokens, err := o.accountClient(account, proxy)
+	if err != nil {
+		return "", err
+	}
+	return tokens.Renew(context.Background())
+}
 
-		return tokenResp.Data.Token, nil
+// accessToken 返回令牌管理器缓存的令牌,没有令牌或即将过期时生成新令牌
+func (o *OpenLedger) accessToken(account string, proxy string) (string, error) {
+	_, tokens, err := o.accountClient(account, proxy)
+	if err != nil {
+		return "", err
 	}
 
-	return "", fmt.Errorf("failed to generate token after %d attempts", maxRetries)
+	previous := tokens.Current()
+	token, err := tokens.Token(context.Background())
+	if err != nil {
+		o.emit(account, &AuthFailed{Renewal: previous != "", Err: err})
+		return "", err
+	}
+	if previous != "" && token != previous {
+		o.emit(account, &TokenGenerated{Renewed: true, ExpiresAt: expiresAt(token)})
+	}
+	return token, nil
 }
 
 // renewToken 更新访问令牌
 func (o *OpenLedger) renewToken(account string, proxy string) (string, error) {
 	token, err := o.generateToken(account, proxy)
 	if err != nil {
-		o.log(fmt.Sprintf("%s Account %s - Failed to Renew Access Token",
-			color.RedString("✗"),
-			color.WhiteString(o.hideAccount(account))))
+		o.emit(account, &AuthFailed{Renewal: true, Err: err})
 		return "", err
 	}
-
-	o.log(fmt.Sprintf("%s Account %s - Access Token Has Been Renewed",
-		color.GreenString("✓"),
-		color.WhiteString(o.hideAccount(account))))
+	o.emit(account, &TokenGenerated{Renewed: true, ExpiresAt: expiresAt(token)})
 
 	return token, nil
 }
 
+// expiresAt 令牌的过期时间,无法解析时为nil
+func expiresAt(token string) *time.Time {
+	if exp, ok := openledger.TokenExpiry(token); ok {
+		return &exp
+	}
+	return nil
+}
+
 // generateUserAgent 生成随机User-Agent
 func (o *OpenLedger) generateUserAgent() string {
-	return "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36"
+	return openledger.DefaultUserAgent
 }
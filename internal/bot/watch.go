@@ -0,0 +1,44 @@
+package bot
+
+import (
+	"os"
+	"time"
+)
+
+// WatchFiles 定期检查文件的修改时间,有变化时发送通知
+func WatchFiles(paths []string, interval time.Duration) <-chan struct{} {
+	changes := make(chan struct{}, 1)
+
+	modTimes := func() map[string]time.Time {
+		result := make(map[string]time.Time, len(paths))
+		for _, path := range paths {
+			if info, err := os.Stat(path); err == nil {
+				result[path] = info.ModTime()
+			}
+		}
+		return result
+	}
+
+	go func() {
+		last := modTimes()
+		for range time.Tick(interval) {
+			current := modTimes()
+			changed := len(current) != len(last)
+			for path, modTime := range current {
+				if !last[path].Equal(modTime) {
+					changed = true
+				}
+			}
+			last = current
+
+			if changed {
+				select {
+				case changes <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+
+	return changes
+}
@@ -1,134 +1,71 @@
 package bot
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"net/http"
+	"sync"
 	"time"
 
 	"github.com/fatih/color"
 	"github.com/gorilla/websocket"
-)
 
-// WebSocket消息类型
-const (
-	MsgTypeRegister  = "REGISTER"
-	MsgTypeHeartbeat = "HEARTBEAT"
-	MsgTypeJob       = "JOB"
-	MsgTypeResponse  = "RESPONSE"
+	"openledger/pkg/openledger"
 )
 
-// 添加心跳响应消息类型
-type HeartbeatResponse struct {
-	Status bool   `json:"status"`
-	Type   string `json:"type"`
+// jobAcker 确认任务的一端,便于回放时替换真实会话
+type jobAcker interface {
+	AckJob(ref interface{}) error
 }
 
-// connectWebSocket 建立WebSocket连接
-func (o *OpenLedger) connectWebSocket(account, token string, proxy string) (*websocket.Conn, error) {
-	// 构建WebSocket URL
-	wsURL := fmt.Sprintf("wss://apitn.openledger.xyz/ws/v1/orch?authToken=%s", token)
-	o.log(fmt.Sprintf("Connecting WebSocket for account %s", o.hideAccount(account)))
-
-	// 设置请求头
-	headers := http.Header{
-		"Accept-Encoding": {"gzip, deflate, br, zstd"},
-		"Accept-Language": {"id-ID,id;q=0.9,en-US;q=0.8,en;q=0.7"},
-		"Cache-Control":   {"no-cache"},
-		"Origin":          {o.extensionID},
-		"Pragma":          {"no-cache"},
-		"User-Agent":      {o.generateUserAgent()},
-	}
-
-	// 创建Dialer
-	dialer := websocket.Dialer{
-		HandshakeTimeout: 45 * time.Second,
-	}
+// connectWebSocket 使用令牌管理器中的令牌建立WebSocket连接
+func (o *OpenLedger) connectWebSocket(account string, proxy string) (*openledger.Session, error) {
+	o.log(fmt.Sprintf("Connecting WebSocket for account %s", o.displayName(account)))
 
-	// 如果有代理,设置代理
-	if proxy != "" {
-		if transport, err := o.getProxyClient(proxy); err == nil {
-			dialer.NetDial = transport.Dial
-		}
+	client, _, err := o.accountClient(account, proxy)
+	if err != nil {
+		return nil, err
 	}
-
-	// 连接WebSocket
-	conn, _, err := dialer.Dial(wsURL, headers)
+	token, err := o.accessToken(account, proxy)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect websocket: %w", err)
+		return nil, err
 	}
-
-	return conn, nil
+	return client.Dial(context.Background(), account, token)
 }
 
 // sendRegisterMessage 发送注册消息
-func (o *OpenLedger) sendRegisterMessage(conn *websocket.Conn, account string) error {
-	id := o.generateID()
-	identity := o.generateWorkerID(account)
-
-	msg := WorkerMessage{
-		WorkerID:   identity,
-		MsgType:    MsgTypeRegister,
-		WorkerType: "LWEXT",
-		Message: RegisterMessage{
-			ID:   id,
-			Type: MsgTypeRegister,
-			Worker: Worker{
-				Host:         o.extensionID,
-				Identity:     identity,
-				OwnerAddress: account,
-				Type:         "LWEXT",
-			},
-		},
-	}
-
-	if err := conn.WriteJSON(msg); err != nil {
-		return fmt.Errorf("failed to send register message: %w", err)
+func (o *OpenLedger) sendRegisterMessage(session *openledger.Session, account string) error {
+	if o.dryRun() {
+		o.logDryRun(account, "register the worker")
+		return nil
 	}
-
-	return nil
+	return session.Register()
 }
 
 // sendHeartbeatMessage 发送心跳消息
-func (o *OpenLedger) sendHeartbeatMessage(conn *websocket.Conn, account string) error {
-	identity := o.generateWorkerID(account)
-	memory := 32.0
-	storage := "500.00"
-
-	msg := WorkerMessage{
-		WorkerID:   identity,
-		MsgType:    MsgTypeHeartbeat,
-		WorkerType: "LWEXT",
-		Message: HeartbeatMessage{
-			Worker: Worker{
-				Identity:     identity,
-				OwnerAddress: account,
-				Type:         "LWEXT",
-				Host:         o.extensionID,
-			},
-			Capacity: Capacity{
-				AvailableMemory:  memory,
-				AvailableStorage: storage,
-				AvailableGPU:     "",
-				AvailableModels:  []string{},
-			},
-		},
+func (o *OpenLedger) sendHeartbeatMessage(session *openledger.Session, account string) error {
+	if o.dryRun() {
+		o.logDryRun(account, "send a heartbeat")
+		return nil
 	}
 
-	if err := conn.WriteJSON(msg); err != nil {
-		return fmt.Errorf("failed to send heartbeat message: %w", err)
+	if err := session.Heartbeat(openledger.DefaultCapacity); err != nil {
+		return err
+	}
+	if streak := o.runtime(account).recordHeartbeatSent(time.Now()); streak == missedAckLimit {
+		o.emit(account, &HeartbeatsMissed{Streak: streak})
 	}
 
 	o.log(fmt.Sprintf("%s Account %s - Heartbeat sent",
 		color.CyanString("["),
-		color.WhiteString(o.hideAccount(account))))
+		color.WhiteString(o.displayName(account))))
 
 	return nil
 }
 
 // handleWebSocketMessage 处理WebSocket消息
-func (o *OpenLedger) handleWebSocketMessage(conn *websocket.Conn, account string) error {
-	_, message, err := conn.ReadMessage()
+func (o *OpenLedger) handleWebSocketMessage(session *openledger.Session, account string) error {
+	message, err := session.ReadMessage()
 	if err != nil {
 		if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 			return fmt.Errorf("websocket read error: %w", err)
@@ -136,117 +73,213 @@ func (o *OpenLedger) handleWebSocketMessage(conn *websocket.Conn, account string
 		return err
 	}
 
-	var msg map[string]interface{}
-	// 解析消息
-	if err := json.Unmarshal(message, &msg); err != nil {
+	return o.dispatchMessage(session, account, message)
+}
+
+// dispatchMessage 处理一条收到的WebSocket消息,收到任务时通过w确认
+func (o *OpenLedger) dispatchMessage(w jobAcker, account string, message []byte) error {
+	msg, err := openledger.ParseMessage(message)
+	if err != nil {
 		o.log(fmt.Sprintf("%s Account %s - Failed to parse message: %v",
 			color.YellowString("!"),
-			color.WhiteString(o.hideAccount(account)),
+			color.WhiteString(o.displayName(account)),
 			err))
 		return nil
 	}
 
-	msgType, ok := msg["msgType"].(string)
-	if !ok {
-		// 尝试其他字段
-		msgType, ok = msg["type"].(string)
-		if !ok {
-			return nil
-		}
-	}
+	switch msg.Type {
+	case "":
+		return nil
 
-	switch msgType {
-	case MsgTypeRegister:
-		o.log(fmt.Sprintf("%s Account %s - WebSocket registered successfully",
-			color.GreenString("✓"),
-			color.WhiteString(o.hideAccount(account))))
+	case openledger.MsgTypeRegister:
+		o.emit(account, &WSRegistered{})
 		return nil
 
-	case MsgTypeHeartbeat:
-		if message, ok := msg["message"].(map[string]interface{}); ok {
-			if status, ok := message["Status"].(bool); ok && status {
-				o.log(fmt.Sprintf("%s Account %s - Heartbeat acknowledged",
-					color.GreenString("✓"),
-					color.WhiteString(o.hideAccount(account))))
-			}
+	case openledger.MsgTypeHeartbeat:
+		if msg.HeartbeatAcked() {
+			latency, _ := o.runtime(account).recordHeartbeatAck(time.Now(), time.Duration(o.settings().HeartbeatInterval))
+			o.emit(account, &HeartbeatAcked{Latency: latency})
 		}
 		return nil
 
-	case MsgTypeJob:
-		response := map[string]interface{}{
-			"workerID":   o.generateWorkerID(account),
-			"msgType":    "JOB_ASSIGNED",
-			"workerType": "LWEXT",
-			"message": map[string]interface{}{
-				"Status": true,
-				"Ref":    msg["UUID"],
-			},
+	case openledger.MsgTypeJob:
+		if o.dryRun() {
+			o.logDryRun(account, fmt.Sprintf("acknowledge job %v", msg.JobID()))
+			return nil
 		}
-		if err := conn.WriteJSON(response); err != nil {
-			return fmt.Errorf("failed to send job response: %w", err)
+		if err := w.AckJob(msg.JobID()); err != nil {
+			return err
 		}
-		o.log(fmt.Sprintf("%s Account %s - Job assigned",
-			color.GreenString("✓"),
-			color.WhiteString(o.hideAccount(account))))
+		o.emit(account, &JobAssigned{JobID: fmt.Sprint(msg.JobID())})
 
-	case MsgTypeResponse:
+	case openledger.MsgTypeResponse:
 		return nil
 
 	default:
 		o.log(fmt.Sprintf("%s Account %s - Unknown message type: %s",
 			color.YellowString("!"),
-			color.WhiteString(o.hideAccount(account)),
-			msgType))
+			color.WhiteString(o.displayName(account)),
+			msg.Type))
 	}
 
 	return nil
 }
 
+// closeClass 服务端关闭WebSocket连接的原因分类
+type closeClass int
+
+const (
+	// closeAbnormal 网络中断或未知关闭码,按递增间隔重连
+	closeAbnormal closeClass = iota
+	// closeRestart 服务端正常关闭或重启,短暂等待后重连,连续发生时间隔递增
+	closeRestart
+	// closeAuth 令牌被拒绝或违反策略,更新令牌后重连,连续多次时挂起账号
+	closeAuth
+	// closeGoingAway 服务端下线,等待较长时间后重连
+	closeGoingAway
+)
+
+// 关闭码对应的重连参数
+const (
+	serviceRestartDelay    = time.Second
+	maxServiceRestartDelay = 30 * time.Second
+	goingAwayDelay         = 2 * time.Minute
+	authRejectLimit        = 3
+	authRejectWindow       = time.Minute
+)
+
+// classifyClose 根据读取错误中的关闭帧分类,没有收到关闭帧时返回 closeAbnormal 和nil
+func classifyClose(err error) (closeClass, *websocket.CloseError) {
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) {
+		return closeAbnormal, nil
+	}
+
+	switch closeErr.Code {
+	case websocket.CloseNormalClosure, websocket.CloseServiceRestart, websocket.CloseTryAgainLater:
+		return closeRestart, closeErr
+	// 3000/3003 为注册的 Unauthorized/Forbidden,4001/4003 为常见的自定义认证失败码
+	case websocket.ClosePolicyViolation, 3000, 3003, 4001, 4003:
+		return closeAuth, closeErr
+	case websocket.CloseGoingAway:
+		return closeGoingAway, closeErr
+	}
+	return closeAbnormal, closeErr
+}
+
 // processWebSocket 处理WebSocket连接
-func (o *OpenLedger) processWebSocket(account, token string, useProxy bool, proxy string, errChan chan<- error) {
+func (o *OpenLedger) processWebSocket(account string, useProxy bool, proxy string, errChan chan<- error) {
 	reconnectDelay := time.Second * 5
-	for o.running {
-		retries := 0
-		maxRetries := 3
+	rt := o.runtime(account)
+	// renewed 上次握手因令牌被拒绝后已更新过令牌
+	renewed := false
+	// authRejects 连接因认证或策略原因被连续关闭的次数
+	authRejects := 0
+	// restarts 连接被服务端正常关闭或重启的连续次数
+	restarts := 0
+	// retries 连续连接失败的次数,超过 maxRetries 后等待30秒再重新计数
+	retries := 0
+	maxRetries := 3
+	for o.active(rt) {
+		// 账号暂停时不建立连接
+		if rt.isPaused() {
+			rt.setWSStatus(wsStatusPaused)
+			o.waitIfPaused(rt)
+			continue
+		}
+
 		// 建立连接
 		actualProxy := ""
 		if useProxy {
 			actualProxy = proxy
 		}
-		conn, err := o.connectWebSocket(account, token, actualProxy)
+		rt.setWSStatus(wsStatusConnecting)
+		// 降级后重新连接时回到 Connecting,首次连接时已处于该状态
+		o.transition(rt, StateConnecting, "reconnecting websocket")
+		conn, err := o.connectWebSocket(account, actualProxy)
 		if err != nil {
+			rt.setWSStatus(wsStatusClosed)
 			errChan <- fmt.Errorf("websocket connection failed: %w", err)
+
+			var handshake *openledger.HandshakeError
+			if errors.As(err, &handshake) {
+				// 令牌被拒绝时更新令牌后立即重连,更新后仍被拒绝则按普通失败等待
+				if handshake.Unauthorized() {
+					if !renewed {
+						renewed = true
+						if _, err := o.renewToken(account, proxy); err == nil {
+							continue
+						}
+					} else {
+						renewed = false
+					}
+				}
+				// 限流或服务端错误时按 Retry-After 等待
+				if handshake.Throttled() {
+					delay := handshake.RetryAfter
+					if delay == 0 {
+						delay = 30 * time.Second
+					}
+					o.log(fmt.Sprintf("%s Account: %s - WebSocket handshake rejected with status %d, retrying in %s",
+						color.YellowString("!"),
+						color.WhiteString(o.displayName(account)),
+						handshake.StatusCode,
+						delay))
+					rt.sleep(delay, rt.reconnect)
+					continue
+				}
+			}
+
 			if retries < maxRetries {
 				retries++
-				time.Sleep(time.Duration(retries) * 5 * time.Second)
+				rt.sleep(time.Duration(retries)*5*time.Second, rt.reconnect)
 				continue
 			}
-			time.Sleep(30 * time.Second)
+			rt.sleep(30*time.Second, rt.reconnect)
 			retries = 0
 			continue
 		}
 
-		// 连接成功后输出状态
-		identity := o.generateWorkerID(account)
-		o.log(fmt.Sprintf("%s Account: %s - Proxy: %s - Worker ID: %s - Status: %s",
-			color.CyanString("["),
-			color.WhiteString(o.hideAccount(account)),
-			color.WhiteString(actualProxy),
-			color.WhiteString(o.hideAccount(identity)),
-			color.GreenString("Webscoket Is Connected")))
+		renewed = false
+		retries = 0
+		rt.setConn(conn)
+		rt.resetHeartbeats()
+		identity := conn.WorkerID()
+		o.emit(account, &WSConnected{Proxy: actualProxy, WorkerID: identity})
 
 		// 发送注册消息
 		if err := o.sendRegisterMessage(conn, account); err != nil {
 			errChan <- fmt.Errorf("register message failed: %w", err)
+			rt.setConn(nil)
+			rt.setWSStatus(wsStatusClosed)
 			conn.Close()
-			time.Sleep(5 * time.Second)
+			rt.sleep(5*time.Second, rt.reconnect)
 			continue
 		}
 
 		// 启动心跳goroutine
-		heartbeatTicker := time.NewTicker(30 * time.Second)
+		var heartbeatWG sync.WaitGroup
+		connDone := make(chan struct{})
+		heartbeatTicker := time.NewTicker(time.Duration(o.settings().HeartbeatInterval))
+		heartbeatWG.Add(1)
 		go func() {
-			for range heartbeatTicker.C {
+			defer heartbeatWG.Done()
+			// panic时断开连接,由重连流程恢复
+			defer func() {
+				if err := o.recovered(account, "heartbeat", recover()); err != nil {
+					errChan <- err
+					conn.Close()
+				}
+			}()
+			for {
+				select {
+				case <-connDone:
+					return
+				case <-heartbeatTicker.C:
+				}
+				if rt.isPaused() {
+					continue
+				}
 				if err := o.sendHeartbeatMessage(conn, account); err != nil {
 					errChan <- fmt.Errorf("heartbeat message failed: %w", err)
 					return
@@ -254,31 +287,113 @@ func (o *OpenLedger) processWebSocket(account, token string, useProxy bool, prox
 			}
 		}()
 
+		// 收到重连命令时关闭当前连接
+		forced := make(chan struct{})
+		go func() {
+			select {
+			case <-rt.reconnect:
+				o.log(fmt.Sprintf("%s Account: %s - WebSocket reconnect requested",
+					color.YellowString("!"),
+					color.WhiteString(o.displayName(account))))
+				close(forced)
+				conn.CloseWithReason(websocket.CloseNormalClosure, "reconnect requested")
+			case <-connDone:
+			}
+		}()
+
 		// 处理消息
-		for o.running {
+		connected := time.Now()
+		var readErr error
+		for o.active(rt) {
 			if err := o.handleWebSocketMessage(conn, account); err != nil {
 				if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 					errChan <- fmt.Errorf("websocket error: %w", err)
 				}
+				readErr = err
 				break
 			}
 		}
 
 		// 清理
+		close(connDone)
 		heartbeatTicker.Stop()
+		rt.setConn(nil)
 		conn.Close()
+		heartbeatWG.Wait()
+		class, closeErr := classifyClose(readErr)
+		closed := &WSClosed{Proxy: actualProxy, WorkerID: identity}
+		if closeErr != nil {
+			closed.Code, closed.Reason = closeErr.Code, closeErr.Text
+		}
+		o.emit(account, closed)
+
+		// 主动要求的重连立即执行
+		select {
+		case <-forced:
+			reconnectDelay = time.Second * 5
+			continue
+		default:
+		}
+
+		if !o.active(rt) {
+			continue
+		}
+
+		// 稳定运行一段时间后重新计算连续被拒绝的次数
+		if time.Since(connected) > authRejectWindow {
+			authRejects = 0
+			restarts = 0
+		}
+
+		// 按服务端的关闭码决定重连方式
+		switch class {
+		case closeRestart:
+			// 服务端反复立即关闭连接时避免空转重连
+			delay := serviceRestartDelay << restarts
+			if delay > maxServiceRestartDelay {
+				delay = maxServiceRestartDelay
+			} else {
+				restarts++
+			}
+			o.log(fmt.Sprintf("%s Account: %s - Server closed the WebSocket (code %d), reconnecting in %s",
+				color.YellowString("!"),
+				color.WhiteString(o.displayName(account)),
+				closed.Code,
+				delay))
+			reconnectDelay = time.Second * 5
+			rt.sleep(delay, rt.reconnect)
+			continue
+
+		case closeAuth:
+			authRejects++
+			if authRejects >= authRejectLimit {
+				o.suspendAccount(rt, authRejects, fmt.Errorf("websocket rejected %d times in a row (code %d: %s)", authRejects, closed.Code, closed.Reason))
+				authRejects = 0
+				o.waitIfPaused(rt)
+				continue
+			}
+			o.log(fmt.Sprintf("%s Account: %s - WebSocket rejected (code %d: %s), renewing token",
+				color.RedString("✗"),
+				color.WhiteString(o.displayName(account)),
+				closed.Code,
+				closed.Reason))
+			if _, err := o.renewToken(account, proxy); err != nil {
+				rt.sleep(reconnectDelay, rt.reconnect)
+			}
+			continue
+
+		case closeGoingAway:
+			o.log(fmt.Sprintf("%s Account: %s - Server is going away, reconnecting in %s",
+				color.YellowString("!"),
+				color.WhiteString(o.displayName(account)),
+				goingAwayDelay))
+			rt.sleep(goingAwayDelay, rt.reconnect)
+			continue
+		}
 
-		// 连接断开后输出状态
-		o.log(fmt.Sprintf("%s Account: %s - Proxy: %s - Worker ID: %s - Status: %s",
-			color.CyanString("["),
-			color.WhiteString(o.hideAccount(account)),
-			color.WhiteString(actualProxy),
-			color.WhiteString(o.hideAccount(identity)),
-			color.YellowString("Webscoket Connection Closed")))
-
-		// 如果程序还在运行,等待后重试
-		if o.running {
-			time.Sleep(reconnectDelay)
+		// 网络中断或未知关闭码,等待后重试
+		if o.active(rt) {
+			rt.sleep(reconnectDelay, rt.reconnect)
 			// 增加重连延迟，最大30秒
 			if reconnectDelay < time.Second*30 {
 				reconnectDelay *= 2
@@ -0,0 +1,307 @@
+// Package cassette 录制和回放 OpenLedger 的HTTP请求和WebSocket消息,
+// 每个账号一个JSON Lines文件,用于离线复现接口结构变化导致的解析问题
+package cassette
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// WebSocket消息方向
+const (
+	Sent     = "sent"
+	Received = "received"
+)
+
+// Entry 录制的一条记录: 一次HTTP请求和响应,或一条WebSocket消息
+type Entry struct {
+	Time     time.Time `json:"time"`
+	Request  *Request  `json:"request,omitempty"`
+	Response *Response `json:"response,omitempty"`
+	Frame    *Frame    `json:"frame,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// Request 录制的HTTP请求
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// Response 录制的HTTP响应
+type Response struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// Frame 录制的WebSocket消息
+type Frame struct {
+	Direction string `json:"direction"`
+	Data      string `json:"data"`
+}
+
+// Redactor 写入文件前脱敏,替换令牌、地址等敏感内容
+type Redactor func(s string) string
+
+// sensitiveHeaders 录制时整体替换的请求头和响应头
+var sensitiveHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "Proxy-Authorization"}
+
+// Recorder 将流量写入目录下的录制文件
+type Recorder struct {
+	dir   string
+	mu    sync.Mutex
+	files map[string]*os.File
+}
+
+// NewRecorder 创建录制目录
+func NewRecorder(dir string) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+	return &Recorder{
+		dir:   dir,
+		files: make(map[string]*os.File),
+	}, nil
+}
+
+// Path 返回录制文件路径
+func (r *Recorder) Path(name string) string {
+	return filepath.Join(r.dir, name+".jsonl")
+}
+
+// Close 关闭所有录制文件
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var firstErr error
+	for name, file := range r.files {
+		if err := file.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(r.files, name)
+	}
+	return firstErr
+}
+
+// write 追加一条记录,录制失败不影响正常请求
+func (r *Recorder) write(name string, entry Entry) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(entry); err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	file, ok := r.files[name]
+	if !ok {
+		var err error
+		file, err = os.OpenFile(r.Path(name), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return
+		}
+		r.files[name] = file
+	}
+	file.Write(buf.Bytes())
+}
+
+// RecordFrame 录制一条WebSocket消息
+func (r *Recorder) RecordFrame(name, direction string, data []byte, redact Redactor) {
+	r.write(name, Entry{
+		Time:  time.Now(),
+		Frame: &Frame{Direction: direction, Data: redact(string(data))},
+	})
+}
+
+// Transport 返回录制经过base的所有请求的RoundTripper
+func (r *Recorder) Transport(name string, base http.RoundTripper, redact Redactor) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &recordingTransport{recorder: r, name: name, base: base, redact: redact}
+}
+
+type recordingTransport struct {
+	recorder *Recorder
+	name     string
+	base     http.RoundTripper
+	redact   Redactor
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var requestBody []byte
+	if req.Body != nil {
+		data, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		requestBody = data
+		req.Body = io.NopCloser(bytes.NewReader(data))
+	}
+
+	entry := Entry{
+		Time: time.Now(),
+		Request: &Request{
+			Method: req.Method,
+			URL:    t.redact(req.URL.String()),
+			Header: t.redactHeader(req.Header),
+			Body:   t.redact(string(requestBody)),
+		},
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		entry.Error = t.redact(err.Error())
+		t.recorder.write(t.name, entry)
+		return nil, err
+	}
+
+	responseBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(responseBody))
+	if err != nil {
+		entry.Error = t.redact(err.Error())
+	}
+
+	entry.Response = &Response{
+		Status: resp.StatusCode,
+		Header: t.redactHeader(resp.Header),
+		Body:   t.redact(string(responseBody)),
+	}
+	t.recorder.write(t.name, entry)
+
+	return resp, nil
+}
+
+func (t *recordingTransport) redactHeader(header http.Header) http.Header {
+	result := make(http.Header, len(header))
+	for key, values := range header {
+		for _, value := range values {
+			result.Add(key, t.redact(value))
+		}
+	}
+	for _, key := range sensitiveHeaders {
+		if result.Get(key) != "" {
+			result.Set(key, "<redacted>")
+		}
+	}
+	return result
+}
+
+// Cassette 已录制的记录,用于回放
+type Cassette struct {
+	Entries []Entry
+
+	mu   sync.Mutex
+	used []bool
+}
+
+// Load 读取录制文件
+func Load(path string) (*Cassette, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	c := &Cassette{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", path, line, err)
+		}
+		c.Entries = append(c.Entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	c.used = make([]bool, len(c.Entries))
+	return c, nil
+}
+
+// Frames 返回指定方向的所有WebSocket消息,按录制顺序排列
+func (c *Cassette) Frames(direction string) [][]byte {
+	var frames [][]byte
+	for _, entry := range c.Entries {
+		if entry.Frame != nil && entry.Frame.Direction == direction {
+			frames = append(frames, []byte(entry.Frame.Data))
+		}
+	}
+	return frames
+}
+
+// Transport 返回按录制顺序回放HTTP响应的RoundTripper
+// 请求按方法、路径和查询参数匹配(忽略主机),每条记录只回放一次
+func (c *Cassette) Transport() http.RoundTripper {
+	return replayTransport{c}
+}
+
+type replayTransport struct {
+	c *Cassette
+}
+
+func (t replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	c := t.c
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, entry := range c.Entries {
+		if c.used[i] || entry.Request == nil || entry.Request.Method != req.Method {
+			continue
+		}
+		recorded, err := url.Parse(entry.Request.URL)
+		if err != nil || recorded.Path != req.URL.Path || recorded.RawQuery != req.URL.RawQuery {
+			continue
+		}
+		c.used[i] = true
+
+		if entry.Response == nil {
+			return nil, fmt.Errorf("cassette: recorded error: %s", entry.Error)
+		}
+		header := entry.Response.Header.Clone()
+		if header == nil {
+			header = make(http.Header)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", entry.Response.Status, http.StatusText(entry.Response.Status)),
+			StatusCode:    entry.Response.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader([]byte(entry.Response.Body))),
+			ContentLength: int64(len(entry.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("cassette: no recorded response for %s %s", req.Method, req.URL.Path)
+}
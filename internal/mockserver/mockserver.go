@@ -0,0 +1,488 @@
+// Package mockserver 基于 httptest 的 OpenLedger 模拟服务器,用于离线测试完整的账号生命周期
+package mockserver
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+
+	"openledger/pkg/openledger"
+)
+
+// 接口名称,用于注入故障和统计请求
+const (
+	EndpointGenerateToken  = "generate_token"
+	EndpointReward         = "reward"
+	EndpointRewardRealtime = "reward_realtime"
+	EndpointWorkerReward   = "worker_reward"
+	EndpointClaimDetails   = "claim_details"
+	EndpointClaimReward    = "claim_reward"
+	EndpointTierDetails    = "tier_details"
+	EndpointClaimTier      = "claim_tier"
+	EndpointWebSocket      = "orch"
+)
+
+// Fault 注入的故障,按注入顺序逐个被请求消耗
+type Fault struct {
+	// Status 返回的HTTP状态码,为0时使用200
+	Status int
+	// Body 原样返回的响应体,可用于构造畸形JSON
+	Body string
+}
+
+// 常用故障
+var (
+	FaultUnauthorized = Fault{Status: http.StatusUnauthorized, Body: `{"message":"unauthorized"}`}
+	FaultNotEligible  = Fault{Status: 420, Body: `{"message":"not eligible"}`}
+	FaultMalformed    = Fault{Status: http.StatusOK, Body: `{"data":`}
+	FaultServerError  = Fault{Status: http.StatusInternalServerError, Body: `internal error`}
+)
+
+// Tier 等级奖励
+type Tier struct {
+	ID      int
+	Name    string
+	Value   float64
+	Claimed bool
+}
+
+// State 服务器模拟的账号数据,所有账号共用
+type State struct {
+	TotalPoint      string
+	TotalHeartbeats string
+	HeartbeatCount  int
+	CheckinClaimed  bool
+	DailyPoint      float64
+	Tiers           []Tier
+	// MuteHeartbeats 为true时不确认心跳,模拟确认丢失
+	MuteHeartbeats bool
+	// UncreditedHeartbeats 为true时确认心跳但不计入 heartbeat_count,模拟服务端丢弃心跳
+	UncreditedHeartbeats bool
+}
+
+// Message 服务器收到的WebSocket消息
+type Message struct {
+	Token   string
+	MsgType string
+	Raw     []byte
+}
+
+// Server OpenLedger 模拟服务器
+type Server struct {
+	server   *httptest.Server
+	upgrader websocket.Upgrader
+
+	mu       sync.Mutex
+	state    State
+	tokens   map[string]string
+	faults   map[string][]Fault
+	counts   map[string]int
+	messages []Message
+	conns    map[*websocket.Conn]string
+}
+
+// New 启动模拟服务器
+func New() *Server {
+	s := &Server{
+		state: State{
+			TotalPoint:      "100.00",
+			TotalHeartbeats: "0",
+			DailyPoint:      10,
+			Tiers: []Tier{
+				{ID: 1, Name: "Bronze", Value: 50},
+				{ID: 2, Name: "Silver", Value: 100},
+			},
+		},
+		upgrader: websocket.Upgrader{
+			// 客户端以浏览器扩展的 Origin 连接
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+		tokens: make(map[string]string),
+		faults: make(map[string][]Fault),
+		counts: make(map[string]int),
+		conns:  make(map[*websocket.Conn]string),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v1/auth/generate_token", s.handleGenerateToken)
+	mux.HandleFunc("GET /api/v1/reward", s.authorized(EndpointReward, s.handleReward))
+	mux.HandleFunc("GET /api/v1/reward_realtime", s.authorized(EndpointRewardRealtime, s.handleRewardRealtime))
+	mux.HandleFunc("GET /api/v1/worker_reward", s.authorized(EndpointWorkerReward, s.handleWorkerReward))
+	mux.HandleFunc("GET /api/v1/claim_details", s.authorized(EndpointClaimDetails, s.handleClaimDetails))
+	mux.HandleFunc("GET /api/v1/claim_reward", s.authorized(EndpointClaimReward, s.handleClaimReward))
+	mux.HandleFunc("GET /api/v1/tier_details", s.authorized(EndpointTierDetails, s.handleTierDetails))
+	mux.HandleFunc("PUT /api/v1/claim_tier", s.authorized(EndpointClaimTier, s.handleClaimTier))
+	mux.HandleFunc("GET /ws/v1/orch", s.handleWebSocket)
+
+	s.server = httptest.NewServer(mux)
+	return s
+}
+
+// Close 关闭服务器和所有WebSocket连接
+func (s *Server) Close() {
+	s.Disconnect()
+	s.server.Close()
+}
+
+// URL 返回HTTP接口的基础地址,同时用于认证和奖励接口
+func (s *Server) URL() string {
+	return s.server.URL
+}
+
+// WebSocketURL 返回 orchestrator WebSocket 地址
+func (s *Server) WebSocketURL() string {
+	return "ws" + strings.TrimPrefix(s.server.URL, "http") + "/ws/v1/orch"
+}
+
+// Endpoints 返回指向本服务器的环境配置,可作为 Config.Profiles 中的一个环境使用
+func (s *Server) Endpoints() openledger.Endpoints {
+	return openledger.Endpoints{
+		Auth:         s.URL(),
+		Rewards:      s.URL(),
+		Orchestrator: s.WebSocketURL(),
+		Origin:       s.URL(),
+	}
+}
+
+// Inject 为接口注入故障,每个请求消耗一个
+func (s *Server) Inject(endpoint string, faults ...Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults[endpoint] = append(s.faults[endpoint], faults...)
+}
+
+// Update 修改模拟数据
+func (s *Server) Update(fn func(state *State)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(&s.state)
+}
+
+// State 返回当前模拟数据的副本
+func (s *Server) State() State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := s.state
+	state.Tiers = append([]Tier(nil), s.state.Tiers...)
+	return state
+}
+
+// Count 返回接口收到的请求数
+func (s *Server) Count(endpoint string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.counts[endpoint]
+}
+
+// Messages 返回收到的所有WebSocket消息
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+// ExpireTokens 使所有已签发的令牌失效,之后的请求返回401
+func (s *Server) ExpireTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens = make(map[string]string)
+}
+
+// Connections 返回当前WebSocket连接数
+func (s *Server) Connections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.conns)
+}
+
+// SendJob 向所有WebSocket连接下发JOB消息,返回任务UUID
+func (s *Server) SendJob() string {
+	id := uuid.New().String()
+	s.Send(map[string]interface{}{
+		"msgType": "JOB",
+		"UUID":    id,
+	})
+	return id
+}
+
+// Send 向所有WebSocket连接发送任意消息
+func (s *Server) Send(v interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.conns {
+		conn.WriteJSON(v)
+	}
+}
+
+// Disconnect 直接断开所有WebSocket连接(异常关闭)
+func (s *Server) Disconnect() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.conns {
+		conn.Close()
+		delete(s.conns, conn)
+	}
+}
+
+// CloseWebSocket 发送关闭帧后断开所有WebSocket连接
+func (s *Server) CloseWebSocket(code int, reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.conns {
+		conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason))
+		conn.Close()
+		delete(s.conns, conn)
+	}
+}
+
+// nextFault 记录请求并取出待注入的故障
+func (s *Server) nextFault(endpoint string) (Fault, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.counts[endpoint]++
+	queue := s.faults[endpoint]
+	if len(queue) == 0 {
+		return Fault{}, false
+	}
+	s.faults[endpoint] = queue[1:]
+	return queue[0], true
+}
+
+func writeFault(w http.ResponseWriter, fault Fault) {
+	status := fault.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	fmt.Fprint(w, fault.Body)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// validToken 判断令牌是否由本服务器签发且未失效
+func (s *Server) validToken(token string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.tokens[token]
+	return ok
+}
+
+// authorized 处理故障注入和Bearer令牌校验
+func (s *Server) authorized(endpoint string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if fault, ok := s.nextFault(endpoint); ok {
+			writeFault(w, fault)
+			return
+		}
+		if !s.validToken(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")) {
+			writeFault(w, FaultUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *Server) handleGenerateToken(w http.ResponseWriter, r *http.Request) {
+	if fault, ok := s.nextFault(EndpointGenerateToken); ok {
+		writeFault(w, fault)
+		return
+	}
+
+	var req struct {
+		Address string `json:"address"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Address == "" {
+		http.Error(w, `{"message":"invalid address"}`, http.StatusBadRequest)
+		return
+	}
+
+	token := newToken(req.Address)
+	s.mu.Lock()
+	s.tokens[token] = req.Address
+	s.mu.Unlock()
+
+	writeJSON(w, map[string]interface{}{
+		"data": map[string]string{"token": token},
+	})
+}
+
+// newToken 签发与真实服务相同结构的JWT令牌(签名部分无意义),有效期24小时
+func newToken(address string) string {
+	encode := func(v interface{}) string {
+		data, _ := json.Marshal(v)
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	header := encode(map[string]string{"alg": "HS256", "typ": "JWT"})
+	payload := encode(map[string]interface{}{
+		"address": address,
+		"jti":     uuid.New().String(),
+		"exp":     time.Now().Add(24 * time.Hour).Unix(),
+	})
+	return header + "." + payload + "." + base64.RawURLEncoding.EncodeToString([]byte(uuid.New().String()))
+}
+
+func (s *Server) handleReward(w http.ResponseWriter, r *http.Request) {
+	state := s.State()
+	writeJSON(w, map[string]interface{}{
+		"data": map[string]string{"totalPoint": state.TotalPoint},
+	})
+}
+
+func (s *Server) handleRewardRealtime(w http.ResponseWriter, r *http.Request) {
+	state := s.State()
+	writeJSON(w, map[string]interface{}{
+		"data": []map[string]string{{"total_heartbeats": state.TotalHeartbeats}},
+	})
+}
+
+func (s *Server) handleWorkerReward(w http.ResponseWriter, r *http.Request) {
+	state := s.State()
+	writeJSON(w, map[string]interface{}{
+		"data": []map[string]string{{
+			"heartbeat_count":  fmt.Sprint(state.HeartbeatCount),
+			"total_heartbeats": state.TotalHeartbeats,
+		}},
+	})
+}
+
+func (s *Server) handleClaimDetails(w http.ResponseWriter, r *http.Request) {
+	state := s.State()
+	writeJSON(w, map[string]interface{}{
+		"data": map[string]interface{}{
+			"claimed":    state.CheckinClaimed,
+			"dailyPoint": state.DailyPoint,
+		},
+	})
+}
+
+func (s *Server) handleClaimReward(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	claimed := !s.state.CheckinClaimed
+	s.state.CheckinClaimed = true
+	s.mu.Unlock()
+
+	writeJSON(w, map[string]interface{}{
+		"data": map[string]bool{"claimed": claimed},
+	})
+}
+
+func (s *Server) handleTierDetails(w http.ResponseWriter, r *http.Request) {
+	state := s.State()
+	tiers := make([]map[string]interface{}, 0, len(state.Tiers))
+	for _, tier := range state.Tiers {
+		tiers = append(tiers, map[string]interface{}{
+			"id":          tier.ID,
+			"name":        tier.Name,
+			"value":       tier.Value,
+			"claimStatus": tier.Claimed,
+		})
+	}
+	writeJSON(w, map[string]interface{}{
+		"data": map[string]interface{}{"tierDetails": tiers},
+	})
+}
+
+// handleClaimTier 领取未领取的等级,已领取或不存在时返回420
+func (s *Server) handleClaimTier(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TierID int `json:"tierId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"message":"invalid request"}`, http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	claimed := false
+	for i, tier := range s.state.Tiers {
+		if tier.ID == req.TierID && !tier.Claimed {
+			s.state.Tiers[i].Claimed = true
+			claimed = true
+		}
+	}
+	s.mu.Unlock()
+
+	if !claimed {
+		writeFault(w, FaultNotEligible)
+		return
+	}
+	writeJSON(w, map[string]string{"status": "SUCCESS"})
+}
+
+// handleWebSocket 模拟 orchestrator: 应答REGISTER和HEARTBEAT,记录所有消息
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	if fault, ok := s.nextFault(EndpointWebSocket); ok {
+		writeFault(w, fault)
+		return
+	}
+
+	token := r.URL.Query().Get("authToken")
+	if !s.validToken(token) {
+		writeFault(w, FaultUnauthorized)
+		return
+	}
+
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	s.conns[conn] = token
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	for {
+		_, raw, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var msg struct {
+			MsgType string `json:"msgType"`
+		}
+		json.Unmarshal(raw, &msg)
+
+		s.mu.Lock()
+		s.messages = append(s.messages, Message{Token: token, MsgType: msg.MsgType, Raw: raw})
+		var reply interface{}
+		switch msg.MsgType {
+		case "REGISTER":
+			reply = map[string]interface{}{"msgType": "REGISTER", "status": true}
+		case "HEARTBEAT":
+			if !s.state.UncreditedHeartbeats {
+				s.state.HeartbeatCount++
+			}
+			if !s.state.MuteHeartbeats {
+				reply = map[string]interface{}{
+					"msgType": "HEARTBEAT",
+					"message": map[string]bool{"Status": true},
+				}
+			}
+		}
+		if reply != nil {
+			conn.WriteJSON(reply)
+		}
+		s.mu.Unlock()
+	}
+}
@@ -0,0 +1,219 @@
+package mockserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// request 发送请求并返回状态码和解析后的响应
+func request(t *testing.T, s *Server, method, path, token string, body interface{}) (int, map[string]interface{}) {
+	t.Helper()
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, s.URL()+path, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var result map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&result)
+	return resp.StatusCode, result
+}
+
+// login 为地址生成令牌
+func login(t *testing.T, s *Server) string {
+	t.Helper()
+	status, result := request(t, s, "POST", "/api/v1/auth/generate_token", "", map[string]string{"address": "0xabc"})
+	if status != http.StatusOK {
+		t.Fatalf("generate_token returned %d", status)
+	}
+	data, _ := result["data"].(map[string]interface{})
+	token, _ := data["token"].(string)
+	if token == "" {
+		t.Fatalf("generate_token returned no token: %v", result)
+	}
+	return token
+}
+
+func TestRewardsRequireToken(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	if status, _ := request(t, s, "GET", "/api/v1/reward", "", nil); status != http.StatusUnauthorized {
+		t.Errorf("reward without token returned %d, want 401", status)
+	}
+
+	token := login(t, s)
+	status, result := request(t, s, "GET", "/api/v1/reward", token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("reward returned %d", status)
+	}
+	if data, _ := result["data"].(map[string]interface{}); data["totalPoint"] != "100.00" {
+		t.Errorf("reward = %v, want totalPoint 100.00", result)
+	}
+
+	s.ExpireTokens()
+	if status, _ := request(t, s, "GET", "/api/v1/reward", token, nil); status != http.StatusUnauthorized {
+		t.Errorf("reward with expired token returned %d, want 401", status)
+	}
+	if got := s.Count(EndpointReward); got != 3 {
+		t.Errorf("Count(reward) = %d, want 3", got)
+	}
+}
+
+func TestInjectedFaultsAreConsumedInOrder(t *testing.T) {
+	s := New()
+	defer s.Close()
+	token := login(t, s)
+
+	s.Inject(EndpointWorkerReward, FaultServerError, FaultUnauthorized)
+	for _, want := range []int{http.StatusInternalServerError, http.StatusUnauthorized, http.StatusOK} {
+		if status, _ := request(t, s, "GET", "/api/v1/worker_reward", token, nil); status != want {
+			t.Errorf("worker_reward returned %d, want %d", status, want)
+		}
+	}
+
+	s.Inject(EndpointGenerateToken, FaultMalformed)
+	req, _ := http.NewRequest("POST", s.URL()+"/api/v1/auth/generate_token", strings.NewReader(`{"address":"0xabc"}`))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != FaultMalformed.Body {
+		t.Errorf("malformed fault returned %q, want %q", body, FaultMalformed.Body)
+	}
+}
+
+func TestClaims(t *testing.T) {
+	s := New()
+	defer s.Close()
+	token := login(t, s)
+
+	for _, want := range []bool{true, false} {
+		_, result := request(t, s, "GET", "/api/v1/claim_reward", token, nil)
+		if data, _ := result["data"].(map[string]interface{}); data["claimed"] != want {
+			t.Errorf("claim_reward = %v, want claimed %v", result, want)
+		}
+	}
+	if !s.State().CheckinClaimed {
+		t.Error("check-in not recorded as claimed")
+	}
+
+	for _, tt := range []struct {
+		tier int
+		want int
+	}{{1, http.StatusOK}, {1, 420}, {99, 420}} {
+		if status, _ := request(t, s, "PUT", "/api/v1/claim_tier", token, map[string]int{"tierId": tt.tier}); status != tt.want {
+			t.Errorf("claim_tier %d returned %d, want %d", tt.tier, status, tt.want)
+		}
+	}
+	if tiers := s.State().Tiers; !tiers[0].Claimed || tiers[1].Claimed {
+		t.Errorf("tiers after claim = %+v", tiers)
+	}
+}
+
+// dial 建立WebSocket连接
+func dial(t *testing.T, s *Server, token string) (*websocket.Conn, *http.Response, error) {
+	t.Helper()
+	return websocket.DefaultDialer.Dial(s.WebSocketURL()+"?authToken="+token, nil)
+}
+
+// readJSON 读取一条消息,超时视为失败
+func readJSON(t *testing.T, conn *websocket.Conn) map[string]interface{} {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var msg map[string]interface{}
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	return msg
+}
+
+func TestWebSocket(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	if _, resp, err := dial(t, s, "invalid"); err == nil || resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("dial with invalid token: %v", err)
+	}
+
+	conn, _, err := dial(t, s, login(t, s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	conn.WriteJSON(map[string]string{"msgType": "REGISTER"})
+	if msg := readJSON(t, conn); msg["msgType"] != "REGISTER" || msg["status"] != true {
+		t.Errorf("REGISTER reply = %v", msg)
+	}
+
+	conn.WriteJSON(map[string]string{"msgType": "HEARTBEAT"})
+	if msg := readJSON(t, conn); msg["msgType"] != "HEARTBEAT" {
+		t.Errorf("HEARTBEAT reply = %v", msg)
+	}
+	if got := s.State().HeartbeatCount; got != 1 {
+		t.Errorf("HeartbeatCount = %d, want 1", got)
+	}
+	if got := s.Connections(); got != 1 {
+		t.Errorf("Connections = %d, want 1", got)
+	}
+
+	id := s.SendJob()
+	if msg := readJSON(t, conn); msg["msgType"] != "JOB" || msg["UUID"] != id {
+		t.Errorf("JOB message = %v, want UUID %s", msg, id)
+	}
+
+	messages := s.Messages()
+	if len(messages) != 2 || messages[0].MsgType != "REGISTER" || messages[1].MsgType != "HEARTBEAT" {
+		t.Errorf("Messages = %+v", messages)
+	}
+
+	s.CloseWebSocket(4001, "token revoked")
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, _, err = conn.ReadMessage()
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != 4001 || closeErr.Text != "token revoked" {
+		t.Errorf("read after CloseWebSocket = %v, want close 4001", err)
+	}
+}
+
+func TestWebSocketInjectedFault(t *testing.T) {
+	s := New()
+	defer s.Close()
+	token := login(t, s)
+
+	s.Inject(EndpointWebSocket, Fault{Status: http.StatusServiceUnavailable})
+	if _, resp, err := dial(t, s, token); err == nil || resp == nil || resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("dial with injected fault: %v", err)
+	}
+	conn, _, err := dial(t, s, token)
+	if err != nil {
+		t.Fatalf("dial after fault: %v", err)
+	}
+	conn.Close()
+}
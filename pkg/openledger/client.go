@@ -0,0 +1,321 @@
+// Package openledger OpenLedger 接口的Go客户端: REST接口、访问令牌管理和WebSocket Worker会话
+package openledger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// 接口名称,用于 ResponseHook 和错误信息
+const (
+	EndpointGenerateToken  = "generate_token"
+	EndpointReward         = "reward"
+	EndpointWorkerReward   = "worker_reward"
+	EndpointRealtimeReward = "reward_realtime"
+	EndpointClaimDetails   = "claim_details"
+	EndpointClaimReward    = "claim_reward"
+	EndpointTierDetails    = "tier_details"
+	EndpointClaimTier      = "claim_tier"
+)
+
+// DefaultExtensionID 浏览器扩展的ID,作为WebSocket的 Origin 和Worker的 host
+const DefaultExtensionID = "chrome-extension://ekbbplmjjgoobhdlffmgeokalelnmjjc"
+
+// DefaultUserAgent 默认的User-Agent
+const DefaultUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36"
+
+var (
+	// ErrUnauthorized 接口返回401,需要更新访问令牌
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrNotEligible 等级奖励暂不可领取(接口返回420)
+	ErrNotEligible = errors.New("not eligible to claim")
+)
+
+// StatusError 接口返回了非2xx状态码,响应内容不会被解析
+// 401 时可以用 errors.Is(err, ErrUnauthorized) 判断需要更新令牌
+type StatusError struct {
+	Endpoint   string
+	StatusCode int
+	// Body 响应内容的开头,便于排查
+	Body string
+}
+
+func (e *StatusError) Error() string {
+	if e.StatusCode == http.StatusUnauthorized {
+		return fmt.Sprintf("%s: %v", e.Endpoint, ErrUnauthorized)
+	}
+	if e.Body == "" {
+		return fmt.Sprintf("%s: unexpected status %d", e.Endpoint, e.StatusCode)
+	}
+	return fmt.Sprintf("%s: unexpected status %d: %s", e.Endpoint, e.StatusCode, e.Body)
+}
+
+func (e *StatusError) Unwrap() error {
+	if e.StatusCode == http.StatusUnauthorized {
+		return ErrUnauthorized
+	}
+	return nil
+}
+
+// maxErrorBody 错误信息中保留的响应内容长度
+const maxErrorBody = 200
+
+// Logger 日志输出,*log.Logger 满足该接口
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// Clock 时间来源,测试时可替换
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+type discardLogger struct{}
+
+func (discardLogger) Printf(string, ...interface{}) {}
+
+// ResponseHook 每次成功解析响应后调用,body 为原始响应,v 为解析结果
+type ResponseHook func(endpoint string, status int, body []byte, v interface{})
+
+// FrameHook 每条发送或收到的WebSocket消息都会调用,direction 为 "sent" 或 "received"
+type FrameHook func(direction string, data []byte)
+
+// Client OpenLedger 接口客户端,可以被多个goroutine同时使用
+type Client struct {
+	httpClient   *http.Client
+	dialer       *websocket.Dialer
+	endpoints    Endpoints
+	logger       Logger
+	clock        Clock
+	userAgent    string
+	extensionID  string
+	responseHook ResponseHook
+	frameHook    FrameHook
+}
+
+// Option 客户端选项
+type Option func(*Client)
+
+// WithHTTPClient 使用指定的HTTP客户端,例如配置了代理的传输层
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) { c.httpClient = httpClient }
+}
+
+// WithDialer 使用指定的WebSocket拨号器
+func WithDialer(dialer *websocket.Dialer) Option {
+	return func(c *Client) { c.dialer = dialer }
+}
+
+// WithEndpoints 使用指定的服务地址,默认为测试网
+func WithEndpoints(endpoints Endpoints) Option {
+	return func(c *Client) { c.endpoints = endpoints.Normalize() }
+}
+
+// WithLogger 输出重试等日志,默认不输出
+func WithLogger(logger Logger) Option {
+	return func(c *Client) { c.logger = logger }
+}
+
+// WithClock 使用指定的时间来源判断令牌是否过期
+func WithClock(clock Clock) Option {
+	return func(c *Client) { c.clock = clock }
+}
+
+// WithUserAgent 使用指定的User-Agent
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) { c.userAgent = userAgent }
+}
+
+// WithExtensionID 使用指定的浏览器扩展ID
+func WithExtensionID(extensionID string) Option {
+	return func(c *Client) { c.extensionID = extensionID }
+}
+
+// WithResponseHook 在解析响应后调用hook,例如检查响应结构
+func WithResponseHook(hook ResponseHook) Option {
+	return func(c *Client) { c.responseHook = hook }
+}
+
+// WithFrameHook 在发送和收到WebSocket消息时调用hook,例如录制
+func WithFrameHook(hook FrameHook) Option {
+	return func(c *Client) { c.frameHook = hook }
+}
+
+// New 创建客户端
+func New(opts ...Option) *Client {
+	endpoints, _ := Profile(DefaultProfile)
+	c := &Client{
+		httpClient:  &http.Client{Timeout: 30 * time.Second},
+		dialer:      &websocket.Dialer{HandshakeTimeout: 45 * time.Second},
+		endpoints:   endpoints,
+		logger:      discardLogger{},
+		clock:       systemClock{},
+		userAgent:   DefaultUserAgent,
+		extensionID: DefaultExtensionID,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Endpoints 返回客户端使用的服务地址
+func (c *Client) Endpoints() Endpoints {
+	return c.endpoints
+}
+
+// GenerateToken 为钱包地址生成访问令牌
+func (c *Client) GenerateToken(ctx context.Context, address string) (string, error) {
+	body, err := json.Marshal(map[string]string{"address": address})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal token request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.endpoints.Auth+"/api/v1/auth/generate_token", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json, text/plain, */*")
+	req.Header.Set("Accept-Language", "id-ID,id;q=0.9,en-US;q=0.8,en;q=0.7")
+	req.Header.Set("Content-Type", "application/json")
+	if c.endpoints.Origin != "" {
+		req.Header.Set("Origin", c.endpoints.Origin)
+		req.Header.Set("Referer", c.endpoints.Origin+"/")
+	}
+
+	var result TokenResponse
+	if err := c.do(req, EndpointGenerateToken, &result); err != nil {
+		return "", err
+	}
+	if result.Data.Token == "" {
+		return "", fmt.Errorf("received empty token")
+	}
+	return result.Data.Token, nil
+}
+
+// Reward 查询累计积分
+func (c *Client) Reward(ctx context.Context, token string) (*UserRewardResponse, error) {
+	var result UserRewardResponse
+	if err := c.rewards(ctx, "GET", EndpointReward, token, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// WorkerReward 查询今日心跳次数
+func (c *Client) WorkerReward(ctx context.Context, token string) (*WorkerRewardResponse, error) {
+	var result WorkerRewardResponse
+	if err := c.rewards(ctx, "GET", EndpointWorkerReward, token, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// RealtimeReward 查询今日心跳积分
+func (c *Client) RealtimeReward(ctx context.Context, token string) (*RealtimeRewardResponse, error) {
+	var result RealtimeRewardResponse
+	if err := c.rewards(ctx, "GET", EndpointRealtimeReward, token, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// CheckinDetails 查询今日签到状态
+func (c *Client) CheckinDetails(ctx context.Context, token string) (*CheckinDetailsResponse, error) {
+	var result CheckinDetailsResponse
+	if err := c.rewards(ctx, "GET", EndpointClaimDetails, token, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ClaimCheckin 领取签到奖励
+func (c *Client) ClaimCheckin(ctx context.Context, token string) (*ClaimCheckinResponse, error) {
+	var result ClaimCheckinResponse
+	if err := c.rewards(ctx, "GET", EndpointClaimReward, token, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// TierDetails 查询等级奖励
+func (c *Client) TierDetails(ctx context.Context, token string) (*TierDetailsResponse, error) {
+	var result TierDetailsResponse
+	if err := c.rewards(ctx, "GET", EndpointTierDetails, token, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ClaimTier 领取等级奖励,暂不可领取时返回 ErrNotEligible
+func (c *Client) ClaimTier(ctx context.Context, token string, tierID int) (*ClaimTierResponse, error) {
+	var result ClaimTierResponse
+	if err := c.rewards(ctx, "PUT", EndpointClaimTier, token, map[string]int{"tierId": tierID}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// rewards 调用奖励服务的接口
+func (c *Client) rewards(ctx context.Context, method, endpoint, token string, data interface{}, v interface{}) error {
+	var body io.Reader
+	if data != nil {
+		jsonData, err := json.Marshal(data)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewReader(jsonData)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.endpoints.Rewards+"/api/v1/"+endpoint, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	return c.do(req, endpoint, v)
+}
+
+// do 发送请求并解析响应,非2xx响应返回 *StatusError
+func (c *Client) do(req *http.Request, endpoint string, v interface{}) error {
+	req.Header.Set("User-Agent", c.userAgent)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 420 && endpoint == EndpointClaimTier {
+		return ErrNotEligible
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return &StatusError{Endpoint: endpoint, StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if err := json.NewDecoder(bytes.NewReader(data)).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if c.responseHook != nil {
+		c.responseHook(endpoint, resp.StatusCode, data, v)
+	}
+	return nil
+}
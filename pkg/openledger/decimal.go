@@ -0,0 +1,194 @@
+package openledger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decimal 精确的十进制数,用于积分字段,值为 coef × 10^-scale
+// 零值表示0,运算返回新值而不修改原值
+type Decimal struct {
+	coef  *big.Int
+	scale int32
+}
+
+// maxExponent 指数的绝对值上限,避免 "1e2000000000" 这样的输入分配巨大的整数
+const maxExponent = 1000
+
+// ParseDecimal 解析十进制数,支持 "12"、"-0.5"、"1.2e3" 形式,空字符串为0
+func ParseDecimal(s string) (Decimal, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Decimal{}, nil
+	}
+
+	mantissa, exponent := s, int64(0)
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.ParseInt(s[i+1:], 10, 32)
+		if err != nil {
+			return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+		}
+		if e > maxExponent || e < -maxExponent {
+			return Decimal{}, fmt.Errorf("decimal %q out of range", s)
+		}
+		mantissa, exponent = s[:i], e
+	}
+
+	digits := mantissa
+	scale := int64(0)
+	if i := strings.IndexByte(mantissa, '.'); i >= 0 {
+		digits = mantissa[:i] + mantissa[i+1:]
+		scale = int64(len(mantissa) - i - 1)
+	}
+	if digits == "" || digits == "-" || digits == "+" || strings.ContainsAny(digits[1:], "+-") {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+
+	coef, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+
+	scale -= exponent
+	if scale < 0 {
+		coef.Mul(coef, pow10(-scale))
+		scale = 0
+	}
+	if scale > 1<<20 {
+		return Decimal{}, fmt.Errorf("decimal %q out of range", s)
+	}
+	return Decimal{coef: coef, scale: int32(scale)}, nil
+}
+
+// DecimalFromInt 由整数创建
+func DecimalFromInt(n int64) Decimal {
+	return Decimal{coef: big.NewInt(n)}
+}
+
+func pow10(n int64) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(n), nil)
+}
+
+func (d Decimal) coefficient() *big.Int {
+	if d.coef == nil {
+		return new(big.Int)
+	}
+	return d.coef
+}
+
+// rescale 返回放大到指定小数位数的系数
+func (d Decimal) rescale(scale int32) *big.Int {
+	coef := new(big.Int).Set(d.coefficient())
+	if scale > d.scale {
+		coef.Mul(coef, pow10(int64(scale-d.scale)))
+	}
+	return coef
+}
+
+// Add 返回 d + other
+func (d Decimal) Add(other Decimal) Decimal {
+	scale := max(d.scale, other.scale)
+	return Decimal{coef: new(big.Int).Add(d.rescale(scale), other.rescale(scale)), scale: scale}
+}
+
+// Sub 返回 d - other
+func (d Decimal) Sub(other Decimal) Decimal {
+	scale := max(d.scale, other.scale)
+	return Decimal{coef: new(big.Int).Sub(d.rescale(scale), other.rescale(scale)), scale: scale}
+}
+
+// Cmp 比较大小,返回 -1、0 或 1
+func (d Decimal) Cmp(other Decimal) int {
+	scale := max(d.scale, other.scale)
+	return d.rescale(scale).Cmp(other.rescale(scale))
+}
+
+// Sign 返回符号
+func (d Decimal) Sign() int {
+	return d.coefficient().Sign()
+}
+
+// IsZero 是否为0
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Float64 转换为近似的浮点数
+func (d Decimal) Float64() float64 {
+	f, _ := new(big.Rat).SetFrac(d.coefficient(), pow10(int64(d.scale))).Float64()
+	return f
+}
+
+// String 返回不带多余尾随零的完整值
+func (d Decimal) String() string {
+	s := d.format(d.scale)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
+
+// StringFixed 四舍五入到指定小数位数
+func (d Decimal) StringFixed(places int32) string {
+	if places >= d.scale {
+		return Decimal{coef: d.rescale(places), scale: places}.format(places)
+	}
+
+	divisor := pow10(int64(d.scale - places))
+	quotient, remainder := new(big.Int).QuoRem(d.coefficient(), divisor, new(big.Int))
+	// 一半及以上远离零取整
+	if new(big.Int).Mul(new(big.Int).Abs(remainder), big.NewInt(2)).Cmp(divisor) >= 0 {
+		quotient.Add(quotient, big.NewInt(int64(d.Sign())))
+	}
+	return Decimal{coef: quotient, scale: places}.format(places)
+}
+
+// format 按系数和小数位数输出
+func (d Decimal) format(scale int32) string {
+	coef := d.coefficient()
+	digits := new(big.Int).Abs(coef).String()
+	sign := ""
+	if coef.Sign() < 0 {
+		sign = "-"
+	}
+	if scale == 0 {
+		return sign + digits
+	}
+	if pad := int(scale) + 1 - len(digits); pad > 0 {
+		digits = strings.Repeat("0", pad) + digits
+	}
+	point := len(digits) - int(scale)
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// MarshalJSON 输出为JSON数字
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON 接受JSON数字、数字字符串、空字符串和null,后两者为0
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		*d = Decimal{}
+		return nil
+	}
+
+	text := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+	}
+
+	parsed, err := ParseDecimal(text)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
@@ -0,0 +1,172 @@
+package openledger
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func mustDecimal(t *testing.T, s string) Decimal {
+	t.Helper()
+	d, err := ParseDecimal(s)
+	if err != nil {
+		t.Fatalf("ParseDecimal(%q): %v", s, err)
+	}
+	return d
+}
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", "0"},
+		{"12", "12"},
+		{" 12 ", "12"},
+		{"-0.5", "-0.5"},
+		{"+3.25", "3.25"},
+		{"1.2e3", "1200"},
+		{"1.5E-2", "0.015"},
+		{"0.000", "0"},
+		{"100.100", "100.1"},
+		{"123456789012345678901234567890.123", "123456789012345678901234567890.123"},
+		{"1e1000", "1" + zeros(1000)},
+	}
+	for _, tt := range tests {
+		if got := mustDecimal(t, tt.in).String(); got != tt.want {
+			t.Errorf("ParseDecimal(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func zeros(n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = '0'
+	}
+	return string(b)
+}
+
+func TestParseDecimalInvalid(t *testing.T) {
+	for _, in := range []string{"abc", "-", "+", "1.2.3", "1-2", "1e", "1ex", "1e2000000000", "1e-1001", "1e1001"} {
+		if d, err := ParseDecimal(in); err == nil {
+			t.Errorf("ParseDecimal(%q) = %s, want error", in, d)
+		}
+	}
+}
+
+func TestDecimalRoundTrip(t *testing.T) {
+	for _, in := range []string{"0", "1", "-1", "0.1", "-12.345", "99999999999999999999.00000000000000000001"} {
+		d := mustDecimal(t, in)
+		again := mustDecimal(t, d.String())
+		if again.Cmp(d) != 0 || again.String() != d.String() {
+			t.Errorf("round trip of %q: got %s", in, again)
+		}
+
+		data, err := json.Marshal(d)
+		if err != nil {
+			t.Fatalf("Marshal(%s): %v", d, err)
+		}
+		var decoded Decimal
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("Unmarshal(%s): %v", data, err)
+		}
+		if decoded.Cmp(d) != 0 {
+			t.Errorf("JSON round trip of %q: got %s", in, decoded)
+		}
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		sum, sub string
+	}{
+		{"1", "2", "3", "-1"},
+		{"0.1", "0.2", "0.3", "-0.1"},
+		{"1.5", "0.25", "1.75", "1.25"},
+		{"100", "0.001", "100.001", "99.999"},
+		{"-2.5", "2.5", "0", "-5"},
+		{"1e3", "1.5e-3", "1000.0015", "999.9985"},
+	}
+	for _, tt := range tests {
+		a, b := mustDecimal(t, tt.a), mustDecimal(t, tt.b)
+		if got := a.Add(b).String(); got != tt.sum {
+			t.Errorf("%s + %s = %s, want %s", tt.a, tt.b, got, tt.sum)
+		}
+		if got := a.Sub(b).String(); got != tt.sub {
+			t.Errorf("%s - %s = %s, want %s", tt.a, tt.b, got, tt.sub)
+		}
+	}
+
+	if got := mustDecimal(t, "0.10").Cmp(mustDecimal(t, "0.1")); got != 0 {
+		t.Errorf("Cmp(0.10, 0.1) = %d, want 0", got)
+	}
+	if got := mustDecimal(t, "-1").Cmp(mustDecimal(t, "0.5")); got != -1 {
+		t.Errorf("Cmp(-1, 0.5) = %d, want -1", got)
+	}
+	var zero Decimal
+	if !zero.IsZero() || zero.String() != "0" || zero.Add(DecimalFromInt(2)).String() != "2" {
+		t.Errorf("zero value Decimal does not behave as 0")
+	}
+}
+
+func TestDecimalStringFixed(t *testing.T) {
+	tests := []struct {
+		in     string
+		places int32
+		want   string
+	}{
+		{"1", 2, "1.00"},
+		{"1.005", 2, "1.01"},
+		{"1.004", 2, "1.00"},
+		{"-1.005", 2, "-1.01"},
+		{"2.5", 0, "3"},
+		{"-2.5", 0, "-3"},
+		{"0.0049", 2, "0.00"},
+		{"123.456", 1, "123.5"},
+		{"0.5", 3, "0.500"},
+	}
+	for _, tt := range tests {
+		if got := mustDecimal(t, tt.in).StringFixed(tt.places); got != tt.want {
+			t.Errorf("StringFixed(%s, %d) = %s, want %s", tt.in, tt.places, got, tt.want)
+		}
+	}
+}
+
+func TestDecimalUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`12.5`, "12.5"},
+		{`"12.5"`, "12.5"},
+		{`""`, "0"},
+		{`null`, "0"},
+		{`"1e2"`, "100"},
+		{`-3`, "-3"},
+	}
+	for _, tt := range tests {
+		var d Decimal
+		if err := json.Unmarshal([]byte(tt.in), &d); err != nil {
+			t.Errorf("Unmarshal(%s): %v", tt.in, err)
+			continue
+		}
+		if got := d.String(); got != tt.want {
+			t.Errorf("Unmarshal(%s) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{`"abc"`, `true`, `"1e99999"`} {
+		var d Decimal
+		if err := json.Unmarshal([]byte(in), &d); err == nil {
+			t.Errorf("Unmarshal(%s) = %s, want error", in, d)
+		}
+	}
+
+	var v struct {
+		Point Decimal `json:"point"`
+	}
+	if err := json.Unmarshal([]byte(`{"point": "7.25"}`), &v); err != nil || v.Point.String() != "7.25" {
+		t.Errorf("Unmarshal into struct field = %s, %v", v.Point, err)
+	}
+}
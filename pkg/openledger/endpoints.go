@@ -0,0 +1,69 @@
+package openledger
+
+import (
+	"sort"
+	"strings"
+)
+
+// Endpoints 一组OpenLedger服务地址
+type Endpoints struct {
+	// Auth 认证接口的基础地址
+	Auth string `json:"auth"`
+	// Rewards 奖励、签到和等级接口的基础地址
+	Rewards string `json:"rewards"`
+	// Orchestrator WebSocket地址
+	Orchestrator string `json:"orchestrator"`
+	// Origin 认证请求的 Origin/Referer
+	Origin string `json:"origin"`
+}
+
+// Normalize 去掉地址末尾的 "/"
+func (e Endpoints) Normalize() Endpoints {
+	e.Auth = strings.TrimRight(e.Auth, "/")
+	e.Rewards = strings.TrimRight(e.Rewards, "/")
+	e.Origin = strings.TrimRight(e.Origin, "/")
+	return e
+}
+
+// DefaultProfile 默认使用的环境
+const DefaultProfile = "testnet"
+
+// builtinProfiles 内置环境
+var builtinProfiles = map[string]Endpoints{
+	"testnet": {
+		Auth:         "https://apitn.openledger.xyz",
+		Rewards:      "https://rewardstn.openledger.xyz",
+		Orchestrator: "wss://apitn.openledger.xyz/ws/v1/orch",
+		Origin:       "https://testnet.openledger.xyz",
+	},
+	// mainnet 按测试网的命名规则去掉 tn 后缀,上线后如有不同请覆盖
+	"mainnet": {
+		Auth:         "https://api.openledger.xyz",
+		Rewards:      "https://rewards.openledger.xyz",
+		Orchestrator: "wss://api.openledger.xyz/ws/v1/orch",
+		Origin:       "https://openledger.xyz",
+	},
+	// local 本地模拟服务器或开发部署
+	"local": {
+		Auth:         "http://127.0.0.1:8080",
+		Rewards:      "http://127.0.0.1:8080",
+		Orchestrator: "ws://127.0.0.1:8080/ws/v1/orch",
+		Origin:       "http://127.0.0.1:8080",
+	},
+}
+
+// Profile 返回内置环境的服务地址
+func Profile(name string) (Endpoints, bool) {
+	endpoints, ok := builtinProfiles[name]
+	return endpoints, ok
+}
+
+// ProfileNames 返回所有内置环境名称
+func ProfileNames() []string {
+	names := make([]string, 0, len(builtinProfiles))
+	for name := range builtinProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,243 @@
+package openledger
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+// WebSocket消息方向,传给 FrameHook
+const (
+	FrameSent     = "sent"
+	FrameReceived = "received"
+)
+
+// DefaultCapacity 浏览器扩展上报的可用资源
+var DefaultCapacity = Capacity{
+	AvailableMemory:  32.0,
+	AvailableStorage: "500.00",
+	AvailableGPU:     "",
+	AvailableModels:  []string{},
+}
+
+// WorkerID 钱包地址对应的Worker ID
+func WorkerID(address string) string {
+	return base64.StdEncoding.EncodeToString([]byte(address))
+}
+
+// Session 一个钱包地址的WebSocket Worker会话,写入可以并发调用,读取只能在一个goroutine中进行
+type Session struct {
+	client   *Client
+	conn     *websocket.Conn
+	address  string
+	workerID string
+	writeMu  sync.Mutex
+}
+
+// Dial 使用访问令牌连接编排服务
+func (c *Client) Dial(ctx context.Context, address, token string) (*Session, error) {
+	wsURL := fmt.Sprintf("%s?authToken=%s", c.endpoints.Orchestrator, url.QueryEscape(token))
+	headers := http.Header{
+		"Accept-Encoding": {"gzip, deflate, br, zstd"},
+		"Accept-Language": {"id-ID,id;q=0.9,en-US;q=0.8,en;q=0.7"},
+		"Cache-Control":   {"no-cache"},
+		"Origin":          {c.extensionID},
+		"Pragma":          {"no-cache"},
+		"User-Agent":      {c.userAgent},
+	}
+
+	conn, resp, err := c.dialer.DialContext(ctx, wsURL, headers)
+	if err != nil {
+		if resp != nil {
+			return nil, fmt.Errorf("failed to connect websocket: %w", newHandshakeError(resp, c.clock.Now(), err))
+		}
+		return nil, fmt.Errorf("failed to connect websocket: %w", err)
+	}
+	return &Session{client: c, conn: conn, address: address, workerID: WorkerID(address)}, nil
+}
+
+// HandshakeError 服务端拒绝了WebSocket握手,没有收到HTTP响应的网络错误不会返回该类型
+// 401/403 时可以用 errors.Is(err, ErrUnauthorized) 判断需要更新令牌
+type HandshakeError struct {
+	// StatusCode 握手响应的HTTP状态码
+	StatusCode int
+	// RetryAfter 响应中 Retry-After 要求的等待时间,没有时为0
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *HandshakeError) Error() string {
+	return fmt.Sprintf("handshake rejected with status %d: %v", e.StatusCode, e.Err)
+}
+
+func (e *HandshakeError) Unwrap() []error {
+	if e.Unauthorized() {
+		return []error{e.Err, ErrUnauthorized}
+	}
+	return []error{e.Err}
+}
+
+// Unauthorized 令牌无效或过期(401/403)
+func (e *HandshakeError) Unauthorized() bool {
+	return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+}
+
+// Throttled 服务端限流或暂时不可用(429/5xx),应等待后重试
+func (e *HandshakeError) Throttled() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+func newHandshakeError(resp *http.Response, now time.Time, err error) *HandshakeError {
+	resp.Body.Close()
+	return &HandshakeError{
+		StatusCode: resp.StatusCode,
+		RetryAfter: retryAfter(resp.Header.Get("Retry-After"), now),
+		Err:        err,
+	}
+}
+
+// retryAfter 解析以秒数或HTTP日期表示的 Retry-After
+func retryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// WorkerID 会话使用的Worker ID
+func (s *Session) WorkerID() string {
+	return s.workerID
+}
+
+// worker 会话的Worker身份
+func (s *Session) worker() Worker {
+	return Worker{
+		Host:         s.client.extensionID,
+		Identity:     s.workerID,
+		OwnerAddress: s.address,
+		Type:         WorkerType,
+	}
+}
+
+// Register 注册Worker
+func (s *Session) Register() error {
+	msg := WorkerMessage{
+		WorkerID:   s.workerID,
+		MsgType:    MsgTypeRegister,
+		WorkerType: WorkerType,
+		Message: RegisterMessage{
+			ID:     uuid.New().String(),
+			Type:   MsgTypeRegister,
+			Worker: s.worker(),
+		},
+	}
+	if err := s.Send(msg); err != nil {
+		return fmt.Errorf("failed to send register message: %w", err)
+	}
+	return nil
+}
+
+// Heartbeat 发送心跳
+func (s *Session) Heartbeat(capacity Capacity) error {
+	msg := WorkerMessage{
+		WorkerID:   s.workerID,
+		MsgType:    MsgTypeHeartbeat,
+		WorkerType: WorkerType,
+		Message: HeartbeatMessage{
+			Worker:   s.worker(),
+			Capacity: capacity,
+		},
+	}
+	if err := s.Send(msg); err != nil {
+		return fmt.Errorf("failed to send heartbeat message: %w", err)
+	}
+	return nil
+}
+
+// AckJob 确认收到任务,ref 为任务消息中的UUID
+func (s *Session) AckJob(ref interface{}) error {
+	msg := WorkerMessage{
+		WorkerID:   s.workerID,
+		MsgType:    MsgTypeJobAssigned,
+		WorkerType: WorkerType,
+		Message:    JobAssignedMessage{Status: true, Ref: ref},
+	}
+	if err := s.Send(msg); err != nil {
+		return fmt.Errorf("failed to send job response: %w", err)
+	}
+	return nil
+}
+
+// Send 以JSON发送一条消息
+func (s *Session) Send(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+	if s.client.frameHook != nil {
+		s.client.frameHook(FrameSent, data)
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return s.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// ReadMessage 读取一条原始消息
+func (s *Session) ReadMessage() ([]byte, error) {
+	_, data, err := s.conn.ReadMessage()
+	if err != nil {
+		return nil, err
+	}
+	if s.client.frameHook != nil {
+		s.client.frameHook(FrameReceived, data)
+	}
+	return data, nil
+}
+
+// Close 关闭连接
+func (s *Session) Close() error {
+	return s.conn.Close()
+}
+
+// CloseWithReason 发送带关闭码和原因的关闭帧后关闭连接,关闭帧最多等待1秒
+func (s *Session) CloseWithReason(code int, reason string) error {
+	message := websocket.FormatCloseMessage(code, reason)
+	err := s.conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(time.Second))
+	if cerr := s.conn.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// ParseMessage 解析收到的消息,消息类型取 msgType 字段,没有时取 type 字段
+func ParseMessage(data []byte) (Message, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return Message{}, err
+	}
+
+	msgType, ok := fields["msgType"].(string)
+	if !ok {
+		msgType, _ = fields["type"].(string)
+	}
+	return Message{Type: msgType, Fields: fields}, nil
+}
@@ -0,0 +1,116 @@
+package openledger
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TokenExpiry 从JWT令牌的exp字段读取过期时间,不校验签名
+func TokenExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(claims.Exp, 0), true
+}
+
+// TokenManager 管理一个钱包地址的访问令牌: 缓存、到期前更新、失败时重试
+type TokenManager struct {
+	client  *Client
+	address string
+
+	// Retries 生成令牌的最大尝试次数
+	Retries int
+	// RenewBefore 令牌在过期前多久视为需要更新
+	RenewBefore time.Duration
+
+	mu    sync.Mutex
+	token string
+}
+
+// NewTokenManager 为钱包地址创建令牌管理器
+func (c *Client) NewTokenManager(address string) *TokenManager {
+	return &TokenManager{
+		client:      c,
+		address:     address,
+		Retries:     5,
+		RenewBefore: time.Minute,
+	}
+}
+
+// Token 返回当前令牌,没有令牌或即将过期时生成新令牌
+func (m *TokenManager) Token(ctx context.Context) (string, error) {
+	m.mu.Lock()
+	token := m.token
+	m.mu.Unlock()
+
+	if token != "" {
+		exp, ok := TokenExpiry(token)
+		if !ok || m.client.clock.Now().Add(m.RenewBefore).Before(exp) {
+			return token, nil
+		}
+	}
+	return m.Renew(ctx)
+}
+
+// Renew 生成新令牌,失败时按递增间隔重试
+func (m *TokenManager) Renew(ctx context.Context) (string, error) {
+	var err error
+	for attempt := 1; attempt <= m.Retries; attempt++ {
+		var token string
+		token, err = m.client.GenerateToken(ctx, m.address)
+		if err == nil {
+			m.Set(token)
+			return token, nil
+		}
+		if attempt == m.Retries || ctx.Err() != nil {
+			break
+		}
+
+		m.client.logger.Printf("Retrying token generation (attempt %d/%d): %v", attempt, m.Retries, err)
+		select {
+		case <-time.After(time.Duration(attempt) * 2 * time.Second):
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	return "", fmt.Errorf("failed to generate token after %d attempts: %w", m.Retries, err)
+}
+
+// Current 返回缓存的令牌,不会生成新令牌
+func (m *TokenManager) Current() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.token
+}
+
+// Set 使用已有的令牌
+func (m *TokenManager) Set(token string) {
+	m.mu.Lock()
+	m.token = token
+	m.mu.Unlock()
+}
+
+// ExpiresAt 当前令牌的过期时间,没有令牌或不是JWT时返回false
+func (m *TokenManager) ExpiresAt() (time.Time, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return TokenExpiry(m.token)
+}
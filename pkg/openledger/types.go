@@ -0,0 +1,146 @@
+package openledger
+
+// WebSocket消息类型
+const (
+	MsgTypeRegister  = "REGISTER"
+	MsgTypeHeartbeat = "HEARTBEAT"
+	MsgTypeJob       = "JOB"
+	MsgTypeResponse  = "RESPONSE"
+	// MsgTypeJobAssigned 确认收到任务的回复
+	MsgTypeJobAssigned = "JOB_ASSIGNED"
+)
+
+// WorkerType 浏览器扩展的Worker类型
+const WorkerType = "LWEXT"
+
+// TokenResponse 生成令牌响应
+type TokenResponse struct {
+	Data struct {
+		Token string `json:"token"`
+	} `json:"data"`
+}
+
+// UserRewardResponse 用户奖励响应
+type UserRewardResponse struct {
+	Data struct {
+		TotalPoint Decimal `json:"totalPoint"`
+	} `json:"data"`
+}
+
+// WorkerRewardResponse 工作者奖励响应
+type WorkerRewardResponse struct {
+	Data []struct {
+		HeartbeatCount  Decimal `json:"heartbeat_count"`
+		TotalHeartbeats Decimal `json:"total_heartbeats"`
+	} `json:"data"`
+}
+
+// RealtimeRewardResponse 实时奖励响应
+type RealtimeRewardResponse struct {
+	Data []struct {
+		TotalHeartbeats Decimal `json:"total_heartbeats"`
+	} `json:"data"`
+}
+
+// CheckinDetailsResponse 签到详情响应
+type CheckinDetailsResponse struct {
+	Data struct {
+		Claimed    bool    `json:"claimed"`
+		DailyPoint Decimal `json:"dailyPoint"`
+	} `json:"data"`
+}
+
+// ClaimCheckinResponse 签到领取响应
+type ClaimCheckinResponse struct {
+	Data struct {
+		Claimed bool `json:"claimed"`
+	} `json:"data"`
+}
+
+// TierDetailsResponse 等级详情响应
+type TierDetailsResponse struct {
+	Data struct {
+		TierDetails []TierDetail `json:"tierDetails"`
+	} `json:"data"`
+}
+
+// TierDetail 单个等级奖励
+type TierDetail struct {
+	ID          int     `json:"id"`
+	Name        string  `json:"name"`
+	Value       Decimal `json:"value"`
+	ClaimStatus bool    `json:"claimStatus"`
+}
+
+// ClaimTierResponse 等级奖励领取响应
+type ClaimTierResponse struct {
+	Status string `json:"status"`
+}
+
+// WorkerMessage 发送到WebSocket的消息外层
+type WorkerMessage struct {
+	WorkerID   string      `json:"workerID"`
+	MsgType    string      `json:"msgType"`
+	WorkerType string      `json:"workerType"`
+	Message    interface{} `json:"message"`
+}
+
+// RegisterMessage 注册消息
+type RegisterMessage struct {
+	ID     string `json:"id"`
+	Type   string `json:"type"`
+	Worker Worker `json:"worker"`
+}
+
+// Worker Worker身份
+type Worker struct {
+	Host         string `json:"host"`
+	Identity     string `json:"identity"`
+	OwnerAddress string `json:"ownerAddress"`
+	Type         string `json:"type"`
+}
+
+// HeartbeatMessage 心跳消息
+type HeartbeatMessage struct {
+	Worker   Worker   `json:"Worker"`
+	Capacity Capacity `json:"Capacity"`
+}
+
+// Capacity 心跳中上报的可用资源
+type Capacity struct {
+	AvailableMemory  float64  `json:"AvailableMemory"`
+	AvailableStorage string   `json:"AvailableStorage"`
+	AvailableGPU     string   `json:"AvailableGPU"`
+	AvailableModels  []string `json:"AvailableModels"`
+}
+
+// JobAssignedMessage 确认任务的消息内容
+type JobAssignedMessage struct {
+	Status bool        `json:"Status"`
+	Ref    interface{} `json:"Ref"`
+}
+
+// Message 收到的WebSocket消息,原始字段保存在 Fields 中
+type Message struct {
+	// Type 消息类型,取 msgType 字段,没有时取 type 字段
+	Type   string
+	Fields map[string]interface{}
+}
+
+// HeartbeatAcked 心跳消息是否被确认
+func (m Message) HeartbeatAcked() bool {
+	if m.Type != MsgTypeHeartbeat {
+		return false
+	}
+	message, ok := m.Fields["message"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	status, ok := message["Status"].(bool)
+	return ok && status
+}
+
+// JobID 任务消息的UUID
+func (m Message) JobID() interface{} {
+	return m.Fields["UUID"]
+}